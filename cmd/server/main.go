@@ -4,9 +4,12 @@ import (
 	"erp/config"
 	"erp/internal/app"
 	"erp/pkg/database"
+	"erp/pkg/logger"
 	"erp/pkg/middleware"
 	"erp/routes"
 	"log"
+	"net/http"
+	"time"
 
 	_ "erp/docs" // 导入 Swagger 文档
 
@@ -44,20 +47,45 @@ import (
 func main() {
 	// 初始化配置
 	config.Init()
+	logger.Init(config.AppConfig.LogLevel, config.AppConfig.LogFormat)
+	if err := config.AppConfig.Validate(); err != nil {
+		log.Fatalf("❌ 配置校验失败: %v", err)
+	}
 
 	// 初始化数据库
 	database.InitDatabase()
+	if err := database.RunMigrations(); err != nil {
+		log.Fatalf("❌ 数据库迁移失败: %v", err)
+	}
+	log.Println("Database migration completed")
 
 	// 设置Gin模式
 	gin.SetMode(config.AppConfig.ServerMode)
 
 	r := gin.Default()
+	// 仅信任配置的代理追加的X-Forwarded-For地址，c.ClientIP()从右向左跳过受信代理取真实客户端IP
+	r.RemoteIPHeaders = []string{"X-Forwarded-For"}
+	var trustedProxies []string
+	if config.AppConfig.TrustProxyHeaders {
+		trustedProxies = config.AppConfig.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("❌ 受信任代理配置错误: %v", err)
+	}
+	// 部分处理器直接将 *gin.Context 作为 context 传递给 service，开启回退以读取请求 context 中的值（用户ID、客户端IP等）
+	r.ContextWithFallback = true
 
 	// 添加 CORS 中间件
 	r.Use(middleware.CORSMiddleware())
 
+	// 记录客户端信息，供审计日志使用
+	r.Use(middleware.AuditContextMiddleware())
+
+	// 根据Accept-Language确定错误信息语言
+	r.Use(middleware.LocaleMiddleware())
+
 	// 创建应用管理器
-	app := app.NewApp(database.GetDB())
+	app := app.NewApp(database.GetDB(), database.GetReadDB())
 
 	// 设置路由
 	routes.SetupRoutes(r, app)
@@ -78,7 +106,18 @@ func main() {
 	log.Printf("🚀 服务器启动在 http://localhost%s", addr)
 	log.Printf("📚 Swagger 文档地址: http://localhost%s/swagger/index.html", addr)
 
-	if err := r.Run(addr); err != nil {
+	// 设置超时，避免慢速客户端长期占用连接（Slowloris）
+	cfg := config.AppConfig
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           r,
+		ReadTimeout:       time.Duration(cfg.ServerReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.ServerReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.ServerWriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.ServerIdleTimeout) * time.Second,
+	}
+
+	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal("❌ 服务器启动失败: ", err)
 	}
 }
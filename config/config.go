@@ -2,8 +2,10 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -26,10 +28,62 @@ type Config struct {
 	// STS配置
 	OSSRoleARN         string
 	OSSRoleSessionName string
+	// API Key配置（服务间调用），key -> 服务名称
+	APIKeys map[string]string
+	// 管理员接口IP白名单（CIDR列表，为空时不限制）
+	AdminAllowedCIDRs []string
+	// 是否信任X-Forwarded-For请求头（部署在代理之后时开启）
+	TrustProxyHeaders bool
+	// 受信任的代理地址（CIDR列表），仅这些代理追加的X-Forwarded-For地址会被采用
+	TrustedProxies []string
+	// 数据库启动连接重试配置
+	DBConnectMaxRetries    int
+	DBConnectRetryInterval int // 初始重试间隔（秒），之后按指数退避
+	// 默认管理员配置（用户表为空时自动创建）
+	AdminUsername string
+	AdminPassword string
+	AdminEmail    string
+	// 请求体大小上限（MB），0表示不限制
+	MaxRequestBodyMB int
+	// JWT密钥轮换：当前密钥ID，以及仍用于验证的历史密钥（kid -> secret）
+	JWTKeyID           string
+	JWTPreviousSecrets map[string]string
+	// JWT时间校验允许的时钟偏差（秒）
+	JWTLeewaySeconds int
+	// STS临时凭证有效期（秒）
+	OSSSTSDurationSeconds int
+	// 允许申请STS凭证的上传目录（上传前缀的第一级）
+	OSSUploadPrefixes []string
+	// 分页配置：全局默认/最大每页数量，以及按资源覆盖（资源名 -> 数量）
+	DefaultPageSize   int
+	MaxPageSize       int
+	ResourcePageSizes map[string]int
+	ResourceMaxSizes  map[string]int
+	// 标签颜色与其他启用标签重复时是否拒绝（否则仅记录警告）
+	TagRejectDuplicateColor bool
+
+	// 只读副本连接串（可选），配置后列表等只读查询走副本
+	DBReadDSN string
+
+	// 日志配置
+	LogLevel  string // debug/info/warn/error
+	LogFormat string // console/json
+
+	// HTTP服务超时（秒），0表示不限制
+	ServerReadTimeout       int
+	ServerReadHeaderTimeout int
+	ServerWriteTimeout      int
+	ServerIdleTimeout       int
+
+	// CORS预检请求缓存时间（秒）
+	CORSMaxAgeSeconds int
 }
 
 var AppConfig *Config
 
+// defaultJWTSecret 未配置 JWT_SECRET 时使用的占位密钥，仅用于本地开发，非 debug 模式下校验会拒绝
+const defaultJWTSecret = "your-super-secret-jwt-key-change-this-in-production"
+
 func Init() {
 	// 加载.env文件
 	if err := godotenv.Load(); err != nil {
@@ -42,7 +96,7 @@ func Init() {
 		DBUser:             getEnv("DB_USER", "postgres"),
 		DBPassword:         getEnv("DB_PASSWORD", "password"),
 		DBName:             getEnv("DB_NAME", "erp_db"),
-		JWTSecret:          getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
+		JWTSecret:          getEnv("JWT_SECRET", defaultJWTSecret),
 		JWTExpireHours:     getEnvAsInt("JWT_EXPIRE_HOURS", 24),
 		ServerPort:         getEnv("SERVER_PORT", "8080"),
 		ServerMode:         getEnv("SERVER_MODE", "debug"),
@@ -52,6 +106,49 @@ func Init() {
 		OSSRegion:          getEnv("OSS_REGION", "cn-beijing"),
 		OSSRoleARN:         getEnv("OSS_ROLE_ARN", ""),
 		OSSRoleSessionName: getEnv("OSS_ROLE_SESSION_NAME", "erp-frontend-upload"),
+		APIKeys:            getEnvAsAPIKeys("API_KEYS"),
+		AdminAllowedCIDRs:  getEnvAsSlice("ADMIN_ALLOWED_CIDRS"),
+		TrustProxyHeaders:  getEnvAsBool("TRUST_PROXY_HEADERS", false),
+		TrustedProxies:     getEnvAsSliceOrDefault("TRUSTED_PROXIES", []string{"127.0.0.1", "::1"}),
+
+		// 数据库连接重试
+		DBConnectMaxRetries:    getEnvAsInt("DB_CONNECT_MAX_RETRIES", 5),
+		DBConnectRetryInterval: getEnvAsInt("DB_CONNECT_RETRY_INTERVAL", 2),
+
+		// 默认管理员
+		AdminUsername: getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+		AdminEmail:    getEnv("ADMIN_EMAIL", "admin@example.com"),
+
+		MaxRequestBodyMB: getEnvAsInt("MAX_REQUEST_BODY_MB", 2),
+
+		// JWT密钥轮换
+		JWTKeyID:           getEnv("JWT_KEY_ID", "default"),
+		JWTPreviousSecrets: getEnvAsKeyValues("JWT_PREVIOUS_SECRETS"),
+		JWTLeewaySeconds:   getEnvAsInt("JWT_LEEWAY_SECONDS", 30),
+
+		OSSSTSDurationSeconds: getEnvAsInt("OSS_STS_DURATION_SECONDS", 3600),
+		OSSUploadPrefixes:     getEnvAsUploadPrefixes("OSS_UPLOAD_PREFIXES", uploadPrefixDefaults),
+
+		// 分页
+		DefaultPageSize:   getEnvAsInt("DEFAULT_PAGE_SIZE", 10),
+		MaxPageSize:       getEnvAsInt("MAX_PAGE_SIZE", 100),
+		ResourcePageSizes: getEnvAsIntMap("RESOURCE_PAGE_SIZES", resourcePageSizeDefaults),
+		ResourceMaxSizes:  getEnvAsIntMap("RESOURCE_MAX_PAGE_SIZES", nil),
+
+		TagRejectDuplicateColor: getEnvAsBool("TAG_REJECT_DUPLICATE_COLOR", false),
+
+		DBReadDSN: getEnv("DB_READ_DSN", ""),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "console"),
+
+		ServerReadTimeout:       getEnvAsInt("SERVER_READ_TIMEOUT", 60),
+		ServerReadHeaderTimeout: getEnvAsInt("SERVER_READ_HEADER_TIMEOUT", 10),
+		ServerWriteTimeout:      getEnvAsInt("SERVER_WRITE_TIMEOUT", 120),
+		ServerIdleTimeout:       getEnvAsInt("SERVER_IDLE_TIMEOUT", 120),
+
+		CORSMaxAgeSeconds: getEnvAsInt("CORS_MAX_AGE_SECONDS", 600),
 	}
 }
 
@@ -71,6 +168,163 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice 解析逗号分隔的配置项
+func getEnvAsSlice(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsSliceOrDefault 解析逗号分隔的配置项，未设置时使用默认值
+func getEnvAsSliceOrDefault(key string, defaultValue []string) []string {
+	if items := getEnvAsSlice(key); len(items) > 0 {
+		return items
+	}
+	return defaultValue
+}
+
+// ParseCIDR 解析CIDR，单个IP地址按主机地址处理（视为/32或/128）
+func ParseCIDR(cidr string) (*net.IPNet, error) {
+	if !strings.Contains(cidr, "/") {
+		if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+			cidr += "/32"
+		} else {
+			cidr += "/128"
+		}
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	return network, err
+}
+
+// getEnvAsAPIKeys 解析API Key配置，格式为 "服务名:key,服务名:key"
+func getEnvAsAPIKeys(key string) map[string]string {
+	keys := make(map[string]string)
+	value := os.Getenv(key)
+	if value == "" {
+		return keys
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Printf("Warning: 忽略格式错误的API Key配置: %s", item)
+			continue
+		}
+		keys[parts[1]] = parts[0]
+	}
+	return keys
+}
+
+// getEnvAsKeyValues 解析键值对配置，格式为 "键:值,键:值"
+func getEnvAsKeyValues(key string) map[string]string {
+	values := make(map[string]string)
+	value := os.Getenv(key)
+	if value == "" {
+		return values
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Printf("Warning: 忽略格式错误的配置项 %s: %s", key, item)
+			continue
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values
+}
+
+// uploadPrefixDefaults 默认允许申请STS凭证的上传目录
+var uploadPrefixDefaults = []string{"product-images", "excel"}
+
+// getEnvAsUploadPrefixes 解析上传目录配置，格式为 "目录,目录"，未配置时使用defaults
+// 兼容旧格式 "目录:类型|类型"：STS授权策略无法限制文件类型，类型部分会被忽略
+func getEnvAsUploadPrefixes(key string, defaults []string) []string {
+	var prefixes []string
+	for _, item := range getEnvAsSlice(key) {
+		if prefix := strings.TrimSpace(strings.SplitN(item, ":", 2)[0]); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return defaults
+	}
+	return prefixes
+}
+
+// resourcePageSizeDefaults 各资源的默认每页数量（未配置时使用）
+var resourcePageSizeDefaults = map[string]int{
+	"audit_log":        20,
+	"webhook_delivery": 20,
+}
+
+// getEnvAsIntMap 解析整数键值对配置，格式为 "键:数量,键:数量"，配置项覆盖defaults中的同名键
+func getEnvAsIntMap(key string, defaults map[string]int) map[string]int {
+	values := make(map[string]int, len(defaults))
+	for name, value := range defaults {
+		values[name] = value
+	}
+
+	for name, value := range getEnvAsKeyValues(key) {
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Warning: 忽略格式错误的配置项 %s: %s:%s", key, name, value)
+			continue
+		}
+		values[name] = intValue
+	}
+	return values
+}
+
+// PageSizeLimits 获取资源的默认每页数量和最大每页数量
+func (c *Config) PageSizeLimits(resource string) (defaultSize, maxSize int) {
+	defaultSize, maxSize = c.DefaultPageSize, c.MaxPageSize
+	if size, ok := c.ResourcePageSizes[resource]; ok {
+		defaultSize = size
+	}
+	if size, ok := c.ResourceMaxSizes[resource]; ok {
+		maxSize = size
+	}
+	if defaultSize > maxSize {
+		defaultSize = maxSize
+	}
+	return defaultSize, maxSize
+}
+
+// ossRegions 支持的OSS区域
+var ossRegions = []string{
+	"cn-beijing", "cn-shanghai", "cn-hangzhou", "cn-shenzhen", "cn-qingdao",
+	"cn-zhangjiakou", "cn-huhehaote", "cn-wulanchabu", "cn-heyuan", "cn-guangzhou",
+	"cn-fuzhou", "cn-wuhan-lr", "cn-chengdu", "cn-nanjing",
+	// 海外区域
+	"ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-5", "ap-northeast-1",
+	"ap-south-1", "us-east-1", "us-west-1", "eu-west-1", "eu-central-1", "me-east-1",
+}
+
+// IsKnownOSSRegion 判断是否为支持的OSS区域
+func IsKnownOSSRegion(region string) bool {
+	for _, known := range ossRegions {
+		if known == region {
+			return true
+		}
+	}
+	return false
+}
+
 // GetOSSEndpoint 根据区域获取OSS端点
 func (c *Config) GetOSSEndpoint() string {
 	return getOSSEndpointByRegion(c.OSSRegion)
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// minJWTSecretLength JWT密钥最小长度
+const minJWTSecretLength = 32
+
+// Validate 校验配置，返回所有错误项；OSS配置不完整时仅输出警告
+func (c *Config) Validate() error {
+	var problems []string
+
+	// JWT配置
+	if c.JWTSecret == "" {
+		problems = append(problems, "JWT_SECRET 不能为空")
+	} else if len(c.JWTSecret) < minJWTSecretLength {
+		problems = append(problems, fmt.Sprintf("JWT_SECRET 长度不能少于%d个字符", minJWTSecretLength))
+	} else if c.JWTSecret == defaultJWTSecret && c.ServerMode != "debug" {
+		problems = append(problems, "JWT_SECRET 不能使用默认值，请在非 debug 模式下配置随机密钥")
+	}
+	if c.JWTExpireHours <= 0 {
+		problems = append(problems, "JWT_EXPIRE_HOURS 必须大于0")
+	}
+	if c.JWTLeewaySeconds < 0 {
+		problems = append(problems, "JWT_LEEWAY_SECONDS 不能小于0")
+	}
+	if c.JWTKeyID == "" {
+		problems = append(problems, "JWT_KEY_ID 不能为空")
+	}
+	if _, ok := c.JWTPreviousSecrets[c.JWTKeyID]; ok {
+		problems = append(problems, "JWT_PREVIOUS_SECRETS 中不能包含当前密钥ID "+c.JWTKeyID)
+	}
+	for kid, secret := range c.JWTPreviousSecrets {
+		if len(secret) < minJWTSecretLength {
+			problems = append(problems, fmt.Sprintf("JWT_PREVIOUS_SECRETS 中密钥 %s 长度不能少于%d个字符", kid, minJWTSecretLength))
+		}
+	}
+
+	// 数据库配置
+	dbFields := map[string]string{
+		"DB_HOST": c.DBHost,
+		"DB_PORT": c.DBPort,
+		"DB_USER": c.DBUser,
+		"DB_NAME": c.DBName,
+	}
+	for _, key := range []string{"DB_HOST", "DB_PORT", "DB_USER", "DB_NAME"} {
+		if dbFields[key] == "" {
+			problems = append(problems, key+" 不能为空")
+		}
+	}
+	if c.DBConnectMaxRetries < 0 {
+		problems = append(problems, "DB_CONNECT_MAX_RETRIES 不能小于0")
+	}
+	if c.DBConnectRetryInterval <= 0 {
+		problems = append(problems, "DB_CONNECT_RETRY_INTERVAL 必须大于0")
+	}
+
+	// 网络访问控制配置
+	for _, cidr := range c.AdminAllowedCIDRs {
+		if _, err := ParseCIDR(cidr); err != nil {
+			problems = append(problems, "ADMIN_ALLOWED_CIDRS 中包含无效地址: "+cidr)
+		}
+	}
+	for _, cidr := range c.TrustedProxies {
+		if _, err := ParseCIDR(cidr); err != nil {
+			problems = append(problems, "TRUSTED_PROXIES 中包含无效地址: "+cidr)
+		}
+	}
+
+	// 其他配置
+	if c.ServerPort == "" {
+		problems = append(problems, "SERVER_PORT 不能为空")
+	}
+	if c.MaxRequestBodyMB < 0 {
+		problems = append(problems, "MAX_REQUEST_BODY_MB 不能小于0")
+	}
+	serverTimeouts := map[string]int{
+		"SERVER_READ_TIMEOUT":        c.ServerReadTimeout,
+		"SERVER_READ_HEADER_TIMEOUT": c.ServerReadHeaderTimeout,
+		"SERVER_WRITE_TIMEOUT":       c.ServerWriteTimeout,
+		"SERVER_IDLE_TIMEOUT":        c.ServerIdleTimeout,
+	}
+	for _, key := range []string{"SERVER_READ_TIMEOUT", "SERVER_READ_HEADER_TIMEOUT", "SERVER_WRITE_TIMEOUT", "SERVER_IDLE_TIMEOUT"} {
+		if serverTimeouts[key] < 0 {
+			problems = append(problems, key+" 不能小于0")
+		}
+	}
+	if c.CORSMaxAgeSeconds < 0 {
+		problems = append(problems, "CORS_MAX_AGE_SECONDS 不能小于0")
+	}
+
+	// 日志配置
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		problems = append(problems, "LOG_LEVEL 必须为 debug/info/warn/error 之一")
+	}
+	switch strings.ToLower(c.LogFormat) {
+	case "console", "json":
+	default:
+		problems = append(problems, "LOG_FORMAT 必须为 console 或 json")
+	}
+
+	// 分页配置
+	if c.DefaultPageSize <= 0 {
+		problems = append(problems, "DEFAULT_PAGE_SIZE 必须大于0")
+	}
+	if c.MaxPageSize <= 0 {
+		problems = append(problems, "MAX_PAGE_SIZE 必须大于0")
+	}
+	for resource, size := range c.ResourcePageSizes {
+		if size <= 0 {
+			problems = append(problems, fmt.Sprintf("RESOURCE_PAGE_SIZES 中 %s 必须大于0", resource))
+		}
+	}
+	for resource, size := range c.ResourceMaxSizes {
+		if size <= 0 {
+			problems = append(problems, fmt.Sprintf("RESOURCE_MAX_PAGE_SIZES 中 %s 必须大于0", resource))
+		}
+	}
+
+	// OSS配置部分设置时给出警告
+	ossFields := map[string]string{
+		"OSS_ACCESS_KEY_ID":     c.OSSAccessKeyID,
+		"OSS_ACCESS_KEY_SECRET": c.OSSAccessKeySecret,
+		"OSS_BUCKET_NAME":       c.OSSBucketName,
+		"OSS_ROLE_ARN":          c.OSSRoleARN,
+	}
+	var missingOSS []string
+	for _, key := range []string{"OSS_ACCESS_KEY_ID", "OSS_ACCESS_KEY_SECRET", "OSS_BUCKET_NAME", "OSS_ROLE_ARN"} {
+		if ossFields[key] == "" {
+			missingOSS = append(missingOSS, key)
+		}
+	}
+	if len(missingOSS) > 0 && len(missingOSS) < len(ossFields) {
+		log.Printf("Warning: OSS配置不完整，缺少: %s，OSS相关功能将不可用", strings.Join(missingOSS, ", "))
+	}
+	// STS凭证有效期范围为900秒到43200秒
+	if c.OSSSTSDurationSeconds < 900 || c.OSSSTSDurationSeconds > 43200 {
+		problems = append(problems, "OSS_STS_DURATION_SECONDS 必须在900到43200之间")
+	}
+	if !IsKnownOSSRegion(c.OSSRegion) {
+		if len(missingOSS) == 0 {
+			// OSS已完整配置时区域错误会导致STS请求失败，直接拒绝启动
+			problems = append(problems, "OSS_REGION 不是支持的区域: "+c.OSSRegion)
+		} else {
+			log.Printf("Warning: OSS_REGION 不是支持的区域: %s", c.OSSRegion)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
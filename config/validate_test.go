@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// validConfig 返回一份可以通过校验的配置
+func validConfig() *Config {
+	return &Config{
+		DBHost:                 "localhost",
+		DBPort:                 "5432",
+		DBUser:                 "postgres",
+		DBName:                 "erp",
+		DBConnectRetryInterval: 2,
+		JWTSecret:              "current-secret-at-least-32-characters",
+		JWTExpireHours:         24,
+		JWTKeyID:               "default",
+		ServerPort:             "8080",
+		LogLevel:               "info",
+		LogFormat:              "console",
+		DefaultPageSize:        10,
+		MaxPageSize:            100,
+		OSSAccessKeyID:         "key-id",
+		OSSAccessKeySecret:     "key-secret",
+		OSSBucketName:          "bucket",
+		OSSRoleARN:             "acs:ram::123:role/upload",
+		OSSRegion:              "cn-beijing",
+		OSSSTSDurationSeconds:  3600,
+	}
+}
+
+// captureLog 捕获校验过程中输出的警告日志
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+func TestValidateOSS(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(c *Config)
+		wantErr     string // 为空表示不应返回错误
+		wantWarning string // 为空表示不检查警告
+	}{
+		{
+			name:   "配置完整",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "未配置OSS",
+			modify: func(c *Config) {
+				c.OSSAccessKeyID, c.OSSAccessKeySecret, c.OSSBucketName, c.OSSRoleARN = "", "", "", ""
+			},
+		},
+		{
+			name:        "OSS配置不完整仅警告",
+			modify:      func(c *Config) { c.OSSBucketName, c.OSSRoleARN = "", "" },
+			wantWarning: "OSS配置不完整，缺少: OSS_BUCKET_NAME, OSS_ROLE_ARN",
+		},
+		{
+			name:        "OSS配置不完整时未知区域仅警告",
+			modify:      func(c *Config) { c.OSSRoleARN, c.OSSRegion = "", "cn-nowhere" },
+			wantWarning: "OSS_REGION 不是支持的区域: cn-nowhere",
+		},
+		{
+			name:    "OSS配置完整时未知区域",
+			modify:  func(c *Config) { c.OSSRegion = "cn-nowhere" },
+			wantErr: "OSS_REGION 不是支持的区域: cn-nowhere",
+		},
+		{
+			name:    "STS有效期过短",
+			modify:  func(c *Config) { c.OSSSTSDurationSeconds = 899 },
+			wantErr: "OSS_STS_DURATION_SECONDS",
+		},
+		{
+			name:    "STS有效期过长",
+			modify:  func(c *Config) { c.OSSSTSDurationSeconds = 43201 },
+			wantErr: "OSS_STS_DURATION_SECONDS",
+		},
+		{
+			name:   "STS有效期边界值",
+			modify: func(c *Config) { c.OSSSTSDurationSeconds = 900 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			c := validConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if tt.wantWarning != "" && !strings.Contains(logs.String(), tt.wantWarning) {
+				t.Fatalf("Validate() warnings = %q, want containing %q", logs.String(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestIsKnownOSSRegion(t *testing.T) {
+	tests := []struct {
+		region string
+		want   bool
+	}{
+		{"cn-beijing", true},
+		{"ap-southeast-1", true},
+		{"", false},
+		{"cn-nowhere", false},
+		{"CN-BEIJING", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsKnownOSSRegion(tt.region); got != tt.want {
+			t.Errorf("IsKnownOSSRegion(%q) = %v, want %v", tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDefaultJWTSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		wantErr bool
+	}{
+		{"debug模式允许默认密钥", "debug", false},
+		{"release模式拒绝默认密钥", "release", true},
+		{"未设置模式拒绝默认密钥", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			c.JWTSecret = defaultJWTSecret
+			c.ServerMode = tt.mode
+
+			err := c.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
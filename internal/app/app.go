@@ -1,13 +1,20 @@
 package app
 
 import (
+	"context"
+	"erp/config"
+	"erp/internal/modules/audit"
+	"erp/internal/modules/maintenance"
 	"erp/internal/modules/product"
 	"erp/internal/modules/source"
 	"erp/internal/modules/tags"
 	"erp/internal/modules/user"
 	"erp/internal/modules/user/handler"
 	"erp/internal/modules/user/repository"
+	"erp/internal/modules/webhook"
+	auditRecorder "erp/pkg/audit"
 	"erp/pkg/oss"
+	webhookDispatcher "erp/pkg/webhook"
 	"log"
 
 	"gorm.io/gorm"
@@ -15,15 +22,19 @@ import (
 
 // App 应用管理器
 type App struct {
-	DB      *gorm.DB
-	User    *user.Module
-	Product *product.Module
-	Source  *source.Module
-	Tags    *tags.Module
+	DB          *gorm.DB
+	ReadDB      *gorm.DB
+	User        *user.Module
+	Product     *product.Module
+	Source      *source.Module
+	Tags        *tags.Module
+	Audit       *audit.Module
+	Maintenance *maintenance.Module
+	Webhook     *webhook.Module
 }
 
-// NewApp 创建应用管理器
-func NewApp(db *gorm.DB) *App {
+// NewApp 创建应用管理器，readDB 为只读副本连接，未配置时传入主库
+func NewApp(db, readDB *gorm.DB) *App {
 	// 初始化OSS客户端
 	if err := oss.InitOSS(); err != nil {
 		log.Printf("Warning: OSS客户端初始化失败: %v", err)
@@ -31,14 +42,43 @@ func NewApp(db *gorm.DB) *App {
 		log.Println("OSS客户端初始化成功")
 	}
 
+	// 创建审计日志模块并启动异步记录器
+	auditModule := audit.NewModule(db, readDB)
+	auditRecorder.Init(auditModule.GetRepository())
+
+	// 创建Webhook模块并启动异步分发器
+	webhookModule := webhook.NewModule(db)
+	webhookDispatcher.Init(webhookModule.GetRepository())
+
+	// 创建用户模块并初始化默认管理员
+	userModule := user.NewModule(db)
+	ensureDefaultAdmin(userModule)
+
 	// 创建商品模块
-	productModule := product.NewModule(db)
+	productModule := product.NewModule(db, readDB)
 	return &App{
-		DB:      db,
-		User:    user.NewModule(db),
-		Product: productModule,
-		Source:  source.NewModule(db),
-		Tags:    tags.NewModule(db),
+		DB:          db,
+		ReadDB:      readDB,
+		User:        userModule,
+		Product:     productModule,
+		Source:      source.NewModule(db, readDB),
+		Tags:        tags.NewModule(db),
+		Audit:       auditModule,
+		Maintenance: maintenance.NewModule(db),
+		Webhook:     webhookModule,
+	}
+}
+
+// ensureDefaultAdmin 首次启动（用户表为空）时根据配置创建默认管理员
+func ensureDefaultAdmin(userModule *user.Module) {
+	cfg := config.AppConfig
+	created, err := userModule.Service.EnsureDefaultAdmin(context.Background(), cfg.AdminUsername, cfg.AdminEmail, cfg.AdminPassword)
+	if err != nil {
+		log.Printf("Warning: 默认管理员初始化失败: %v", err)
+		return
+	}
+	if created {
+		log.Printf("⚠️ 用户表为空，已创建默认管理员账户: %s，请尽快登录并修改密码", cfg.AdminUsername)
 	}
 }
 
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"erp/internal/modules/audit/repository"
+	"erp/internal/modules/audit/service"
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditLogHandler struct {
+	svc service.AuditLogService
+}
+
+func NewAuditLogHandler(svc service.AuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{svc: svc}
+}
+
+// @Summary 获取审计日志列表
+// @Description 分页获取操作审计日志（需要管理员权限），支持按操作人、实体类型和日期范围筛选
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码，默认1" default(1)
+// @Param page_size query int false "每页数量，默认20" default(20)
+// @Param user_id query int false "操作人ID"
+// @Param entity_type query string false "实体类型，例如 product, source, tag, user"
+// @Param entity_id query int false "实体ID"
+// @Param action query string false "操作类型: create, update, delete"
+// @Param start_date query string false "开始日期，格式 2006-01-02"
+// @Param end_date query string false "结束日期（包含当天），格式 2006-01-02"
+// @Success 200 {object} response.Response{data=object{items=[]internal_modules_audit_model.AuditLog,total=int64,page=int,page_size=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /audit-logs [get]
+func (h *AuditLogHandler) List(c *gin.Context) {
+	page, pageSize := response.PageParams(c, "audit_log", "page_size")
+
+	var filter repository.AuditLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("筛选参数错误: "+err.Error()))
+		return
+	}
+
+	logs, total, err := h.svc.ListAuditLogs(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取审计日志成功", gin.H{
+		"items":     logs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}))
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// 操作类型
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionIssueCredentials 签发临时凭证（如OSS STS）
+	ActionIssueCredentials = "issue_credentials"
+)
+
+// JSON 原始JSON数据，用于存储操作详情
+type JSON json.RawMessage
+
+// Value 实现 driver.Valuer 接口
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (j *JSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[0:0], v...)
+	case string:
+		*j = JSON(v)
+	default:
+		return errors.New("无法将值转换为 JSON")
+	}
+	return nil
+}
+
+// MarshalJSON 原样输出JSON
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON 原样保存JSON
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[0:0], data...)
+	return nil
+}
+
+// AuditLog 操作审计日志
+// @Description 操作审计日志
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"index" example:"1"`                                     // 操作人ID（服务调用为0）
+	Action     string    `json:"action" gorm:"type:varchar(50);not null;index" example:"update"`       // 操作类型
+	EntityType string    `json:"entity_type" gorm:"type:varchar(50);not null;index" example:"product"` // 实体类型
+	EntityID   uint      `json:"entity_id" gorm:"index" example:"1"`                                   // 实体ID
+	Detail     JSON      `json:"detail" gorm:"type:json" swaggertype:"object"`                         // 操作详情
+	IP         string    `json:"ip" gorm:"type:varchar(64)" example:"127.0.0.1"`                       // 客户端IP
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"erp/internal/modules/audit/handler"
+	"erp/internal/modules/audit/repository"
+	"erp/internal/modules/audit/service"
+
+	"gorm.io/gorm"
+)
+
+type Module struct {
+	db      *gorm.DB
+	repo    repository.AuditLogRepository
+	handler *handler.AuditLogHandler
+}
+
+// NewModule 创建模块，readDB 为只读副本连接（可为nil，此时只读查询使用主库）
+func NewModule(db, readDB *gorm.DB) *Module {
+	// 创建依赖
+	repo := repository.NewAuditLogRepository(db, readDB)
+	svc := service.NewAuditLogService(repo)
+	h := handler.NewAuditLogHandler(svc)
+
+	return &Module{
+		db:      db,
+		repo:    repo,
+		handler: h,
+	}
+}
+
+// GetHandler 获取审计日志处理器
+func (m *Module) GetHandler() *handler.AuditLogHandler {
+	return m.handler
+}
+
+// GetRepository 获取审计日志仓库
+func (m *Module) GetRepository() repository.AuditLogRepository {
+	return m.repo
+}
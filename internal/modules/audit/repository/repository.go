@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"erp/internal/modules/audit/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter 审计日志筛选条件
+type AuditLogFilter struct {
+	UserID     *uint      `form:"user_id"`     // 操作人ID
+	EntityType string     `form:"entity_type"` // 实体类型
+	EntityID   *uint      `form:"entity_id"`   // 实体ID
+	Action     string     `form:"action"`      // 操作类型
+	StartTime  *time.Time `form:"start_date" time_format:"2006-01-02"`
+	EndTime    *time.Time `form:"end_date" time_format:"2006-01-02"`
+}
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *model.AuditLog) error
+	List(ctx context.Context, filter AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error)
+}
+
+type auditLogRepository struct {
+	db     *gorm.DB
+	readDB *gorm.DB // 只读查询（列表、统计）使用的连接，未配置只读副本时与db相同
+}
+
+func NewAuditLogRepository(db, readDB *gorm.DB) AuditLogRepository {
+	if readDB == nil {
+		readDB = db
+	}
+	return &auditLogRepository{db: db, readDB: readDB}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filter AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := r.readDB.WithContext(ctx).Model(&model.AuditLog{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.StartTime != nil {
+		query = query.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		// 结束日期包含当天
+		query = query.Where("created_at < ?", filter.EndTime.AddDate(0, 0, 1))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error
+
+	return logs, total, err
+}
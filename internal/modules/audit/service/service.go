@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"erp/internal/modules/audit/model"
+	"erp/internal/modules/audit/repository"
+)
+
+type AuditLogService interface {
+	ListAuditLogs(ctx context.Context, filter repository.AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error)
+}
+
+type auditLogService struct {
+	repo repository.AuditLogRepository
+}
+
+func NewAuditLogService(repo repository.AuditLogRepository) AuditLogService {
+	return &auditLogService{repo: repo}
+}
+
+func (s *auditLogService) ListAuditLogs(ctx context.Context, filter repository.AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	return s.repo.List(ctx, filter, page, pageSize)
+}
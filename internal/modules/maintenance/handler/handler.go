@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"erp/internal/modules/maintenance/service"
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MaintenanceHandler struct {
+	svc service.MaintenanceService
+}
+
+func NewMaintenanceHandler(svc service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{svc: svc}
+}
+
+// PurgeDeletedRequest 清理软删除数据请求
+type PurgeDeletedRequest struct {
+	EntityTypes   []string `json:"entity_types" binding:"required,min=1" example:"product,color"` // 实体类型: user, product, color
+	OlderThanDays int      `json:"older_than_days" binding:"min=0" example:"90"`                  // 仅清理软删除超过指定天数的记录
+	DryRun        *bool    `json:"dry_run,omitempty" example:"true"`                              // 是否仅统计不删除，默认true
+}
+
+// @Summary 清理软删除数据
+// @Description 硬删除软删除超过指定天数的记录（需要管理员权限），默认只统计不删除（dry_run）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PurgeDeletedRequest true "清理参数"
+// @Success 200 {object} response.Response{data=object{dry_run=bool,cutoff=string,counts=map[string]int64}} "清理成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /admin/maintenance/purge-deleted [post]
+func (h *MaintenanceHandler) PurgeDeleted(c *gin.Context) {
+	var req PurgeDeletedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	dryRun := true
+	if req.DryRun != nil {
+		dryRun = *req.DryRun
+	}
+	olderThan := time.Duration(req.OlderThanDays) * 24 * time.Hour
+
+	counts, cutoff, err := h.svc.PurgeDeleted(c.Request.Context(), req.EntityTypes, olderThan, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedEntity) {
+			c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	message := "清理软删除数据成功"
+	if dryRun {
+		message = "统计待清理数据成功"
+	}
+	c.JSON(http.StatusOK, response.Success(message, gin.H{
+		"dry_run": dryRun,
+		"cutoff":  cutoff,
+		"counts":  counts,
+	}))
+}
@@ -0,0 +1,31 @@
+package maintenance
+
+import (
+	"erp/internal/modules/maintenance/handler"
+	"erp/internal/modules/maintenance/repository"
+	"erp/internal/modules/maintenance/service"
+
+	"gorm.io/gorm"
+)
+
+type Module struct {
+	db      *gorm.DB
+	handler *handler.MaintenanceHandler
+}
+
+func NewModule(db *gorm.DB) *Module {
+	// 创建依赖
+	repo := repository.NewMaintenanceRepository(db)
+	svc := service.NewMaintenanceService(repo)
+	h := handler.NewMaintenanceHandler(svc)
+
+	return &Module{
+		db:      db,
+		handler: h,
+	}
+}
+
+// GetHandler 获取系统维护处理器
+func (m *Module) GetHandler() *handler.MaintenanceHandler {
+	return m.handler
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	productModel "erp/internal/modules/product/model"
+	userModel "erp/internal/modules/user/model"
+
+	"gorm.io/gorm"
+)
+
+// 支持清理的实体类型（仅限使用 gorm.DeletedAt 软删除的模型）
+const (
+	EntityUser    = "user"
+	EntityProduct = "product"
+	EntityColor   = "color"
+)
+
+// joinTable 硬删除实体前需要一并清理的关联表
+type joinTable struct {
+	table  string
+	column string
+}
+
+// purgeTarget 可清理实体的模型及其关联表
+type purgeTarget struct {
+	model      interface{}
+	joinTables []joinTable
+}
+
+var purgeTargets = map[string]purgeTarget{
+	EntityUser: {model: &userModel.User{}},
+	EntityProduct: {
+		model: &productModel.Product{},
+		joinTables: []joinTable{
+			{table: "product_colors", column: "product_id"},
+			{table: "product_tags", column: "product_id"},
+		},
+	},
+	EntityColor: {
+		model: &productModel.Color{},
+		joinTables: []joinTable{
+			{table: "product_colors", column: "color_id"},
+		},
+	},
+}
+
+// IsSupportedEntity 判断实体类型是否支持清理
+func IsSupportedEntity(entityType string) bool {
+	_, ok := purgeTargets[entityType]
+	return ok
+}
+
+type MaintenanceRepository interface {
+	CountDeleted(ctx context.Context, entityType string, cutoff time.Time) (int64, error)
+	PurgeDeleted(ctx context.Context, entityType string, cutoff time.Time, batchSize int) (int64, error)
+}
+
+type maintenanceRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) MaintenanceRepository {
+	return &maintenanceRepository{db: db}
+}
+
+// CountDeleted 统计在截止时间之前软删除的记录数
+func (r *maintenanceRepository) CountDeleted(ctx context.Context, entityType string, cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(purgeTargets[entityType].model).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Count(&count).Error
+	return count, err
+}
+
+// PurgeDeleted 分批硬删除在截止时间之前软删除的记录及其关联表数据，返回删除的记录数
+func (r *maintenanceRepository) PurgeDeleted(ctx context.Context, entityType string, cutoff time.Time, batchSize int) (int64, error) {
+	target := purgeTargets[entityType]
+	var total int64
+
+	for {
+		var ids []uint
+		err := r.db.WithContext(ctx).Unscoped().
+			Model(target.model).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, join := range target.joinTables {
+				if err := tx.Exec("DELETE FROM "+join.table+" WHERE "+join.column+" IN ?", ids).Error; err != nil {
+					return err
+				}
+			}
+
+			result := tx.Unscoped().Delete(target.model, ids)
+			if result.Error != nil {
+				return result.Error
+			}
+			total += result.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+	}
+}
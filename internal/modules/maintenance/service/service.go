@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	auditModel "erp/internal/modules/audit/model"
+	"erp/internal/modules/maintenance/repository"
+	"erp/pkg/audit"
+)
+
+// purgeBatchSize 每批硬删除的记录数
+const purgeBatchSize = 500
+
+// ErrUnsupportedEntity 请求了不支持清理的实体类型
+var ErrUnsupportedEntity = errors.New("不支持的实体类型")
+
+type MaintenanceService interface {
+	PurgeDeleted(ctx context.Context, entityTypes []string, olderThan time.Duration, dryRun bool) (map[string]int64, time.Time, error)
+}
+
+type maintenanceService struct {
+	repo repository.MaintenanceRepository
+}
+
+func NewMaintenanceService(repo repository.MaintenanceRepository) MaintenanceService {
+	return &maintenanceService{repo: repo}
+}
+
+// PurgeDeleted 清理软删除超过指定时长的记录，dryRun为true时只统计不删除，返回各实体的数量及实际使用的截止时间
+func (s *maintenanceService) PurgeDeleted(ctx context.Context, entityTypes []string, olderThan time.Duration, dryRun bool) (map[string]int64, time.Time, error) {
+	for _, entityType := range entityTypes {
+		if !repository.IsSupportedEntity(entityType) {
+			return nil, time.Time{}, fmt.Errorf("%w: %s", ErrUnsupportedEntity, entityType)
+		}
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	counts := make(map[string]int64, len(entityTypes))
+	for _, entityType := range entityTypes {
+		var count int64
+		var err error
+		if dryRun {
+			count, err = s.repo.CountDeleted(ctx, entityType, cutoff)
+		} else {
+			count, err = s.repo.PurgeDeleted(ctx, entityType, cutoff, purgeBatchSize)
+		}
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		counts[entityType] = count
+	}
+
+	if !dryRun {
+		audit.Record(ctx, auditModel.ActionDelete, "purge", 0, map[string]interface{}{
+			"cutoff": cutoff,
+			"counts": counts,
+		})
+	}
+
+	return counts, cutoff, nil
+}
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"erp/internal/modules/product/model"
 	"erp/internal/modules/product/repository"
@@ -36,6 +38,8 @@ type CreateProductRequest struct {
 	Colors        []string            `json:"colors" example:"['黑色','白色','蓝色']"`
 	Tags          []uint              `json:"tags" example:"[1,2,3]"` // 标签ID列表
 	ShippingTime  string              `json:"shipping_time" example:"三天"`
+	Stock         int                 `json:"stock" binding:"min=0" example:"100"`        // 库存数量
+	ReorderLevel  int                 `json:"reorder_level" binding:"min=0" example:"10"` // 补货阈值
 }
 
 // UpdateProductRequest 更新商品请求（字段都是可选的）
@@ -52,6 +56,14 @@ type UpdateProductRequest struct {
 	Colors        *[]string            `json:"colors,omitempty" example:"['黑色','白色','蓝色']"`
 	Tags          *[]uint              `json:"tags,omitempty" example:"[1,2,3]"` // 标签ID列表
 	ShippingTime  *string              `json:"shipping_time,omitempty" example:"三天"`
+	Stock         *int                 `json:"stock,omitempty" binding:"omitempty,min=0" example:"100"`        // 库存数量
+	ReorderLevel  *int                 `json:"reorder_level,omitempty" binding:"omitempty,min=0" example:"10"` // 补货阈值
+	Version       int                  `json:"version" binding:"required,min=1" example:"1"`                   // 当前版本号（必填），用于检测并发修改
+}
+
+// isNotFound 判断是否为商品不存在：查询返回 gorm.ErrRecordNotFound，更新时记录已被删除返回"商品不存在"
+func isNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "商品不存在"
 }
 
 // @Summary 创建商品
@@ -68,7 +80,7 @@ type UpdateProductRequest struct {
 func (h *ProductHandler) Create(c *gin.Context) {
 	var req CreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -83,6 +95,8 @@ func (h *ProductHandler) Create(c *gin.Context) {
 		IsEnabled:     req.IsEnabled,
 		Images:        req.Images,
 		ShippingTime:  req.ShippingTime,
+		Stock:         req.Stock,
+		ReorderLevel:  req.ReorderLevel,
 	}
 
 	if err := h.svc.CreateProduct(c.Request.Context(), product, req.Colors, req.Tags); err != nil {
@@ -105,6 +119,7 @@ func (h *ProductHandler) Create(c *gin.Context) {
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 404 {object} response.Response "商品不存在"
+// @Failure 409 {object} response.Response "数据已被他人修改，请刷新"
 // @Router /product/{id} [put]
 func (h *ProductHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -113,11 +128,11 @@ func (h *ProductHandler) Update(c *gin.Context) {
 		return
 	}
 
-	// 先检查商品是否存在
-	_, err = h.svc.GetProduct(c.Request.Context(), uint(id))
+	// 先获取现有商品，请求中未提供的字段保持原值（如库存、补货阈值）
+	product, err := h.svc.GetProduct(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "商品不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -126,15 +141,10 @@ func (h *ProductHandler) Update(c *gin.Context) {
 
 	var req UpdateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
-	// 更新商品信息，只更新提供的字段
-	product := &model.Product{
-		ID: uint(id),
-	}
-
 	// 只更新提供的字段
 	if req.Name != nil {
 		product.Name = *req.Name
@@ -166,7 +176,15 @@ func (h *ProductHandler) Update(c *gin.Context) {
 	if req.ShippingTime != nil {
 		product.ShippingTime = *req.ShippingTime
 	}
+	if req.Stock != nil {
+		product.Stock = *req.Stock
+	}
+	if req.ReorderLevel != nil {
+		product.ReorderLevel = *req.ReorderLevel
+	}
+	product.Version = req.Version
 
+	// 未提供颜色时传nil，保留原有颜色
 	var colors []string
 	if req.Colors != nil {
 		colors = *req.Colors
@@ -178,8 +196,10 @@ func (h *ProductHandler) Update(c *gin.Context) {
 	}
 
 	if err := h.svc.UpdateProduct(c.Request.Context(), product, colors, tags); err != nil {
-		if err.Error() == "商品不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+		} else if err.Error() == "数据已被他人修改，请刷新" {
+			c.JSON(http.StatusConflict, response.LocalizedError(c, err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -209,8 +229,8 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.svc.DeleteProduct(c.Request.Context(), uint(id)); err != nil {
-		if err.Error() == "商品不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -221,13 +241,15 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 }
 
 // @Summary 获取商品详情
-// @Description 获取指定ID的商品详细信息
+// @Description 获取指定ID的商品详细信息，支持ETag条件请求（If-None-Match匹配时返回304）
 // @Tags 商品管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "商品ID"
+// @Param If-None-Match header string false "上次响应的ETag"
 // @Success 200 {object} response.Response{data=model.Product} "获取成功"
+// @Success 304 "商品未修改"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 404 {object} response.Response "商品不存在"
@@ -241,8 +263,8 @@ func (h *ProductHandler) Get(c *gin.Context) {
 
 	product, err := h.svc.GetProduct(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "商品不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -269,26 +291,17 @@ func (h *ProductHandler) Get(c *gin.Context) {
 // @Param is_discounted query boolean false "是否优惠筛选"
 // @Param is_enabled query boolean false "是否启用筛选"
 // @Param colors query []string false "颜色筛选（可多选）"
+// @Param color_match query string false "颜色匹配方式: any（包含任一颜色，默认）, all（包含全部颜色）" Enums(any, all)
 // @Param shipping_time query string false "发货时间筛选（模糊匹配）"
 // @Param order_by query string false "排序字段: id, name, sku, product_code, price, discount_price, cost_price, is_discounted, is_enabled, shipping_time, created_at, updated_at" Enums(id, name, sku, product_code, price, discount_price, cost_price, is_discounted, is_enabled, shipping_time, created_at, updated_at)
 // @Param order_dir query string false "排序方向: asc, desc" Enums(asc, desc)
+// @Param include query string false "预加载的关联数据，逗号分隔: source, colors, tags, none（默认全部）"
 // @Success 200 {object} response.Response{data=object{items=[]model.Product,total=int64,page=int,page_size=int,total_pages=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Router /product [get]
 func (h *ProductHandler) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// 限制每页最大数量
-	if pageSize > 100 {
-		pageSize = 100
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-	if page <= 0 {
-		page = 1
-	}
+	page, pageSize := response.PageParams(c, "product", "page_size")
 
 	// 构建筛选条件，支持排序
 	var filter repository.ProductListFilter
@@ -297,14 +310,26 @@ func (h *ProductHandler) List(c *gin.Context) {
 		return
 	}
 
-	// 调试日志
-	println("DEBUG: Received order_by =", filter.OrderBy, "order_dir =", filter.OrderDir)
-	println("DEBUG: Raw query params - order_by =", c.Query("order_by"), "order_dir =", c.Query("order_dir"))
-	println("DEBUG: All query params:")
-	for key, values := range c.Request.URL.Query() {
-		println("  ", key, "=", values)
+	switch filter.ColorMatch {
+	case "":
+		filter.ColorMatch = repository.ColorMatchAny
+	case repository.ColorMatchAny, repository.ColorMatchAll:
+	default:
+		c.JSON(http.StatusBadRequest, response.Error("筛选参数错误: color_match 只能为 any 或 all"))
+		return
+	}
+
+	if _, err := repository.ParseInclude(filter.Include); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("筛选参数错误: "+err.Error()))
+		return
 	}
 
+	slog.Debug("product list request",
+		"order_by", filter.OrderBy,
+		"order_dir", filter.OrderDir,
+		"query", c.Request.URL.RawQuery,
+	)
+
 	// 统一使用高级筛选方法，支持排序
 	products, total, err := h.svc.ListProductsWithFilter(c.Request.Context(), filter, page, pageSize)
 	if err != nil {
@@ -345,7 +370,7 @@ type CreateColorRequest struct {
 func (h *ProductHandler) CreateColor(c *gin.Context) {
 	var req CreateColorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -359,7 +384,7 @@ func (h *ProductHandler) CreateColor(c *gin.Context) {
 }
 
 // @Summary 获取颜色列表
-// @Description 获取所有颜色列表，支持排序
+// @Description 获取所有颜色列表，支持排序；每个颜色附带 product_count（使用该颜色的未删除商品数量）
 // @Tags 商品管理
 // @Accept json
 // @Produce json
@@ -382,6 +407,52 @@ func (h *ProductHandler) ListColors(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Success("获取颜色列表成功", colors))
 }
 
+// maxBatchColorIDs 批量获取颜色时单次允许的最大ID数量
+const maxBatchColorIDs = 200
+
+// @Summary 批量获取颜色
+// @Description 根据逗号分隔的ID列表一次获取多个颜色，按请求顺序返回，不存在的ID会被忽略
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param ids query string true "颜色ID列表，逗号分隔" example(1,2,3)
+// @Success 200 {object} response.Response{data=[]model.Color} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /product/colors/batch [get]
+func (h *ProductHandler) GetColorsBatch(c *gin.Context) {
+	var ids []uint
+	for _, item := range strings.Split(c.Query("ids"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error("无效的颜色ID: "+item))
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, response.Error("ids不能为空"))
+		return
+	}
+	if len(ids) > maxBatchColorIDs {
+		c.JSON(http.StatusBadRequest, response.Error("单次最多获取"+strconv.Itoa(maxBatchColorIDs)+"个颜色"))
+		return
+	}
+
+	colors, err := h.svc.GetColorsByIDs(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取颜色列表成功", colors))
+}
+
 // @Summary 获取颜色详情
 // @Description 获取指定ID的颜色详细信息
 // @Tags 商品管理
@@ -404,7 +475,7 @@ func (h *ProductHandler) GetColor(c *gin.Context) {
 	color, err := h.svc.GetColor(c.Request.Context(), uint(id))
 	if err != nil {
 		if err.Error() == "record not found" {
-			c.JSON(http.StatusNotFound, response.Error("颜色不存在"))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "颜色不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -436,14 +507,14 @@ func (h *ProductHandler) UpdateColor(c *gin.Context) {
 
 	var req CreateColorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
 	color, err := h.svc.UpdateColor(c.Request.Context(), uint(id), req.Name, req.Code, req.HexColor)
 	if err != nil {
 		if err.Error() == "record not found" {
-			c.JSON(http.StatusNotFound, response.Error("颜色不存在"))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "颜色不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -474,7 +545,7 @@ func (h *ProductHandler) DeleteColor(c *gin.Context) {
 
 	if err := h.svc.DeleteColor(c.Request.Context(), uint(id)); err != nil {
 		if err.Error() == "record not found" {
-			c.JSON(http.StatusNotFound, response.Error("颜色不存在"))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "颜色不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -494,7 +565,7 @@ type UpdateImageOrderRequest struct {
 }
 
 // @Summary 更新商品图片顺序
-// @Description 更新商品的图片排序和主图设置
+// @Description 更新商品的图片排序和主图设置，保存时排序值会规范为从0开始的连续序列（主图在前）
 // @Tags 商品管理
 // @Accept json
 // @Produce json
@@ -515,15 +586,15 @@ func (h *ProductHandler) UpdateImageOrder(c *gin.Context) {
 
 	var req UpdateImageOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
 	// 获取商品信息
 	product, err := h.svc.GetProduct(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "商品不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -542,8 +613,9 @@ func (h *ProductHandler) UpdateImageOrder(c *gin.Context) {
 		return
 	}
 
-	// 更新图片信息
+	// 更新图片信息，并将排序值规范为连续序列
 	product.Images = req.Images
+	product.NormalizeImageSort()
 
 	// 更新商品
 	if err := h.svc.UpdateProduct(c.Request.Context(), product, []string{}, []uint{}); err != nil {
@@ -581,15 +653,15 @@ func (h *ProductHandler) SetMainImage(c *gin.Context) {
 
 	var req SetMainImageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
 	// 获取商品信息
 	product, err := h.svc.GetProduct(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "商品不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -621,6 +693,390 @@ func (h *ProductHandler) SetMainImage(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Success("主图设置成功", product))
 }
 
+// @Summary 获取低库存商品
+// @Description 获取库存不高于补货阈值的商品，按库存升序排列；未设置补货阈值的商品使用threshold参数作为阈值
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param threshold query int false "默认补货阈值（用于未设置补货阈值的商品），不传时忽略这些商品"
+// @Param page query int false "页码，默认1" default(1)
+// @Param page_size query int false "每页数量，默认10" default(10)
+// @Success 200 {object} response.Response{data=object{items=[]model.Product,total=int64,page=int,page_size=int,total_pages=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /product/low-stock [get]
+func (h *ProductHandler) ListLowStock(c *gin.Context) {
+	page, pageSize := response.PageParams(c, "product_low_stock", "page_size")
+
+	var threshold *int
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, response.Error("无效的补货阈值"))
+			return
+		}
+		threshold = &parsed
+	}
+
+	products, total, err := h.svc.ListLowStockProducts(c.Request.Context(), threshold, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	c.JSON(http.StatusOK, response.Success("获取低库存商品成功", gin.H{
+		"items":       products,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	}))
+}
+
+// @Summary 获取缺少图片的商品
+// @Description 获取没有图片或没有主图的商品，用于完善商品资料
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param missing query string false "筛选方式: any（没有图片或没有主图，默认）, images（没有任何图片）, main（有图片但没有主图）" Enums(any, images, main)
+// @Param page query int false "页码，默认1" default(1)
+// @Param page_size query int false "每页数量，默认10" default(10)
+// @Success 200 {object} response.Response{data=object{items=[]model.Product,total=int64,page=int,page_size=int,total_pages=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /product/without-images [get]
+func (h *ProductHandler) ListWithoutImages(c *gin.Context) {
+	page, pageSize := response.PageParams(c, "product_without_images", "page_size")
+
+	missing := c.DefaultQuery("missing", repository.MissingImagesAny)
+	switch missing {
+	case repository.MissingImagesAny, repository.MissingImagesNone, repository.MissingImagesMain:
+	default:
+		c.JSON(http.StatusBadRequest, response.Error("missing 参数只能是 any、images 或 main"))
+		return
+	}
+
+	products, total, err := h.svc.ListProductsMissingImages(c.Request.Context(), missing, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	c.JSON(http.StatusOK, response.Success("获取缺图商品成功", gin.H{
+		"items":       products,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	}))
+}
+
+// @Summary 获取相关商品
+// @Description 获取与指定商品共享标签的其他已启用商品，按共享标签数量降序排列
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param page query int false "页码，默认1" default(1)
+// @Param page_size query int false "每页数量，默认10" default(10)
+// @Success 200 {object} response.Response{data=object{items=[]model.Product,total=int64,page=int,page_size=int,total_pages=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Router /product/{id}/related [get]
+func (h *ProductHandler) ListRelated(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的商品ID"))
+		return
+	}
+
+	page, pageSize := response.PageParams(c, "product_related", "page_size")
+
+	products, total, err := h.svc.ListRelatedProducts(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+		} else {
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	c.JSON(http.StatusOK, response.Success("获取相关商品成功", gin.H{
+		"items":       products,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	}))
+}
+
+// SetProductTagsRequest 设置商品标签请求
+type SetProductTagsRequest struct {
+	TagIDs []uint `json:"tag_ids" binding:"required" example:"1,2,3"` // 标签ID列表，空数组表示清除全部标签
+}
+
+// @Summary 设置商品标签
+// @Description 用给定的标签列表替换商品的全部标签（事务内先清除再添加），任一标签不存在时不做修改
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param request body SetProductTagsRequest true "标签ID列表"
+// @Success 200 {object} response.Response{data=model.Product} "设置成功"
+// @Failure 400 {object} response.Response "请求参数错误或标签不存在"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Router /product/{id}/tags [put]
+func (h *ProductHandler) SetTags(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的商品ID"))
+		return
+	}
+
+	var req SetProductTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	product, err := h.svc.SetProductTags(c.Request.Context(), uint(id), req.TagIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+		case err.Error() == "标签不存在":
+			c.JSON(http.StatusBadRequest, response.LocalizedError(c, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("商品标签设置成功", product))
+}
+
+// @Summary 清除商品全部颜色
+// @Description 删除商品的全部颜色关联
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} response.Response{data=model.Product} "清除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Router /product/{id}/colors [delete]
+func (h *ProductHandler) ClearColors(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的商品ID"))
+		return
+	}
+
+	h.removeColors(c, uint(id), nil)
+}
+
+// @Summary 移除商品的单个颜色
+// @Description 删除商品与指定颜色的关联
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param color_id path int true "颜色ID"
+// @Success 200 {object} response.Response{data=model.Product} "移除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在或未关联该颜色"
+// @Router /product/{id}/colors/{color_id} [delete]
+func (h *ProductHandler) RemoveColor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的商品ID"))
+		return
+	}
+	colorID, err := strconv.ParseUint(c.Param("color_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的颜色ID"))
+		return
+	}
+
+	cid := uint(colorID)
+	h.removeColors(c, uint(id), &cid)
+}
+
+// removeColors 移除商品颜色关联并返回更新后的商品
+func (h *ProductHandler) removeColors(c *gin.Context, id uint, colorID *uint) {
+	product, err := h.svc.RemoveProductColors(c.Request.Context(), id, colorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+		case err.Error() == "商品未关联该颜色":
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("商品颜色已移除", product))
+}
+
+// AssignSourceRequest 批量设置商品货源请求
+type AssignSourceRequest struct {
+	ProductIDs []uint `json:"product_ids" binding:"required,min=1,max=500" example:"1,2,3"` // 商品ID列表
+	SourceID   uint   `json:"source_id" binding:"required" example:"1"`                     // 货源ID
+}
+
+// @Summary 批量设置商品货源
+// @Description 为多个商品设置同一货源，并按新货源重新生成商品编码
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AssignSourceRequest true "商品ID列表和货源ID"
+// @Success 200 {object} response.Response{data=object} "设置成功，返回受影响数量"
+// @Failure 400 {object} response.Response "请求参数错误或货源不存在"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Failure 409 {object} response.Response "商品编码已存在"
+// @Router /product/source [patch]
+func (h *ProductHandler) AssignSource(c *gin.Context) {
+	var req AssignSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	affected, err := h.svc.AssignSource(c.Request.Context(), req.ProductIDs, req.SourceID)
+	if err != nil {
+		switch err.Error() {
+		case "货源不存在":
+			c.JSON(http.StatusBadRequest, response.LocalizedError(c, err.Error()))
+		case "商品不存在":
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, err.Error()))
+		case "商品编码已存在":
+			c.JSON(http.StatusConflict, response.LocalizedError(c, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("批量设置货源成功", gin.H{"affected": affected}))
+}
+
+// CloneProductRequest 复制商品请求
+type CloneProductRequest struct {
+	SKU string `json:"sku" binding:"required,max=50" example:"IPHONE14-128G-WHITE"` // 新商品货号
+}
+
+// @Summary 复制商品
+// @Description 以新的货号复制商品，复制名称（追加"副本"）、价格、图片、颜色、标签和发货时间
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param request body CloneProductRequest true "新商品货号"
+// @Success 200 {object} response.Response{data=model.Product} "复制成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Failure 409 {object} response.Response "商品SKU已存在"
+// @Router /product/{id}/clone [post]
+func (h *ProductHandler) Clone(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的商品ID"))
+		return
+	}
+
+	var req CloneProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	product, err := h.svc.CloneProduct(c.Request.Context(), uint(id), req.SKU)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+		case err.Error() == "商品SKU已存在" || err.Error() == "商品编码已存在":
+			c.JSON(http.StatusConflict, response.LocalizedError(c, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("商品复制成功", product))
+}
+
+// MoveImageRequest 移动图片位置请求
+type MoveImageRequest struct {
+	URL         string `json:"url" binding:"required" example:"https://example.com/image1.jpg"` // 图片URL
+	NewPosition *int   `json:"new_position" binding:"required,min=0" example:"0"`               // 新位置（从0开始）
+}
+
+// @Summary 移动商品图片位置
+// @Description 将指定图片移动到新的位置，并重新编排所有图片的排序值
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param request body MoveImageRequest true "图片URL和新位置"
+// @Success 200 {object} response.Response{data=model.Product} "移动成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Router /product/{id}/images/move [post]
+func (h *ProductHandler) MoveImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的商品ID"))
+		return
+	}
+
+	var req MoveImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	product, err := h.svc.MoveImage(c.Request.Context(), uint(id), req.URL, *req.NewPosition)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+		case err.Error() == "指定的图片不存在" || err.Error() == "图片位置超出范围":
+			c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("图片位置更新成功", product))
+}
+
 // GetByCode 通过SKU获取商品
 // @Summary 通过SKU获取商品
 // @Description 通过SKU获取商品详情，并记录查询历史
@@ -645,14 +1101,110 @@ func (h *ProductHandler) GetByCode(c *gin.Context) {
 	// 从上下文中获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, response.Error("未获取到用户信息"))
+		c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "未获取到用户信息"))
 		return
 	}
 
 	product, err := h.svc.GetByCode(c.Request.Context(), code, userID.(uint))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, response.Error("商品不存在"))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.Error("获取商品信息失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取商品成功", product))
+}
+
+// ListQueryHistory 获取商品查询历史
+// @Summary 获取商品查询历史
+// @Description 获取当前用户最近的商品查询记录（按时间倒序）；管理员可通过user_id查看指定用户的记录，user_id=0表示所有用户
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "用户ID（仅管理员）"
+// @Param page query int false "页码，默认1" default(1)
+// @Param page_size query int false "每页数量，默认10" default(10)
+// @Success 200 {object} response.Response{data=object{items=[]model.ProductQueryHistory,total=int64,page=int,page_size=int,total_pages=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /product/query-history [get]
+func (h *ProductHandler) ListQueryHistory(c *gin.Context) {
+	page, pageSize := response.PageParams(c, "product_query_history", "page_size")
+
+	currentUserID := c.GetUint("user_id")
+	userID := &currentUserID
+	if value := c.Query("user_id"); value != "" {
+		if c.GetString("role") != "admin" {
+			c.JSON(http.StatusForbidden, response.LocalizedError(c, "权限不足"))
+			return
+		}
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error("无效的用户ID"))
+			return
+		}
+		if parsed == 0 {
+			userID = nil
+		} else {
+			id := uint(parsed)
+			userID = &id
+		}
+	}
+
+	histories, total, err := h.svc.ListQueryHistory(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	c.JSON(http.StatusOK, response.Success("获取查询历史成功", gin.H{
+		"items":       histories,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	}))
+}
+
+// GetByProductCode 通过商品编码精确获取商品
+// @Summary 通过商品编码获取商品
+// @Description 通过商品编码（店铺编号-货号）精确获取商品详情，用于扫码枪录入，并记录查询历史
+// @Tags 商品管理
+// @Accept json
+// @Produce json
+// @Param code path string true "商品编码"
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.Product} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "商品不存在"
+// @Failure 500 {object} response.Response "系统错误"
+// @Router /product/product-code/{code} [get]
+func (h *ProductHandler) GetByProductCode(c *gin.Context) {
+	code := c.Param("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, response.Error("商品编码不能为空"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "未获取到用户信息"))
+		return
+	}
+
+	product, err := h.svc.GetByProductCode(c.Request.Context(), code, userID.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 			return
 		}
 		c.JSON(http.StatusInternalServerError, response.Error("获取商品信息失败"))
@@ -686,7 +1238,7 @@ func (h *ProductHandler) GetBySKU(c *gin.Context) {
 	product, err := h.svc.GetBySKU(c.Request.Context(), sku)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, response.Error("商品不存在"))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "商品不存在"))
 			return
 		}
 		c.JSON(http.StatusInternalServerError, response.Error("获取商品信息失败"))
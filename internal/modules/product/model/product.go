@@ -4,7 +4,10 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"sort"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Source 货源模型的引用，避免循环导入
@@ -95,24 +98,29 @@ type Tag struct {
 // Product 商品模型
 // @Description 商品信息
 type Product struct {
-	ID            uint          `json:"id" gorm:"primaryKey"`
-	CreatedAt     time.Time     `json:"createdAt"`
-	UpdatedAt     time.Time     `json:"updatedAt"`
-	DeletedAt     *time.Time    `json:"deletedAt,omitempty" gorm:"index"`
-	Name          string        `json:"name" gorm:"type:varchar(100);not null" example:"iPhone 14"`                   // 商品名称
-	SKU           string        `json:"sku" gorm:"type:varchar(50);not null" example:"IPHONE14-128G-BLACK"`           // 货号
-	ProductCode   string        `json:"product_code" gorm:"type:varchar(100)" example:"APPLE001-IPHONE14-128G-BLACK"` // 商品编码（店铺编号-货号）
-	SourceID      *uint         `json:"source_id" gorm:"index" example:"1"`                                           // 货源ID
-	Source        *Source       `json:"source,omitempty" gorm:"foreignKey:SourceID"`                                  // 关联的货源信息
-	Price         float64       `json:"price" gorm:"type:decimal(10,2);not null" example:"6999.00"`                   // 售价
-	IsDiscounted  bool          `json:"is_discounted" gorm:"default:false" example:"true"`                            // 是否优惠
-	DiscountPrice float64       `json:"discount_price" gorm:"type:decimal(10,2)" example:"6799.00"`                   // 优惠价格
-	CostPrice     float64       `json:"cost_price" gorm:"type:decimal(10,2);not null" example:"5999.00"`              // 进货价
-	Images        ProductImages `json:"images" gorm:"type:json"`                                                      // 商品图片列表
-	Colors        []Color       `json:"colors" gorm:"many2many:product_colors;"`                                      // 颜色列表
-	Tags          []Tag         `json:"tags" gorm:"many2many:product_tags;"`                                          // 标签列表
-	ShippingTime  string        `json:"shipping_time" gorm:"type:varchar(50)" example:"三天"`                           // 发货时间
-	IsEnabled     bool          `json:"is_enabled" gorm:"default:true" example:"true"`                                // 是否启用
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	Name          string         `json:"name" gorm:"type:varchar(100);not null" example:"iPhone 14"`                   // 商品名称
+	SKU           string         `json:"sku" gorm:"type:varchar(50);not null" example:"IPHONE14-128G-BLACK"`           // 货号
+	ProductCode   string         `json:"product_code" gorm:"type:varchar(100)" example:"APPLE001-IPHONE14-128G-BLACK"` // 商品编码（店铺编号-货号）
+	SourceID      *uint          `json:"source_id" gorm:"index" example:"1"`                                           // 货源ID
+	Source        *Source        `json:"source,omitempty" gorm:"foreignKey:SourceID"`                                  // 关联的货源信息
+	Price         float64        `json:"price" gorm:"type:decimal(10,2);not null" example:"6999.00"`                   // 售价
+	IsDiscounted  bool           `json:"is_discounted" gorm:"default:false" example:"true"`                            // 是否优惠
+	DiscountPrice float64        `json:"discount_price" gorm:"type:decimal(10,2)" example:"6799.00"`                   // 优惠价格
+	CostPrice     float64        `json:"cost_price" gorm:"type:decimal(10,2);not null" example:"5999.00"`              // 进货价
+	Images        ProductImages  `json:"images" gorm:"type:json"`                                                      // 商品图片列表
+	Colors        []Color        `json:"colors" gorm:"many2many:product_colors;"`                                      // 颜色列表
+	Tags          []Tag          `json:"tags" gorm:"many2many:product_tags;"`                                          // 标签列表
+	ShippingTime  string         `json:"shipping_time" gorm:"type:varchar(50)" example:"三天"`                           // 发货时间
+	IsEnabled     bool           `json:"is_enabled" gorm:"default:true" example:"true"`                                // 是否启用
+	CreatedBy     uint           `json:"created_by" gorm:"index" example:"1"`                                          // 创建人ID
+	UpdatedBy     uint           `json:"updated_by" example:"1"`                                                       // 最后修改人ID
+	Stock         int            `json:"stock" gorm:"default:0;index" example:"100"`                                   // 库存数量
+	ReorderLevel  int            `json:"reorder_level" gorm:"default:0" example:"10"`                                  // 补货阈值，0表示未设置
+	Version       int            `json:"version" gorm:"default:1;not null" example:"1"`                                // 版本号（乐观锁）
 }
 
 // GenerateProductCode 生成商品编码：店铺编号-货号
@@ -140,23 +148,27 @@ func (p *Product) GetMainImage() *ProductImage {
 func (p *Product) GetSortedImages() []ProductImage {
 	images := make([]ProductImage, len(p.Images))
 	copy(images, []ProductImage(p.Images))
+	sortImages(images)
+	return images
+}
 
-	// 按sort字段排序，主图优先
-	for i := 0; i < len(images)-1; i++ {
-		for j := i + 1; j < len(images); j++ {
-			// 主图优先
-			if images[j].IsMain && !images[i].IsMain {
-				images[i], images[j] = images[j], images[i]
-			} else if images[i].IsMain == images[j].IsMain {
-				// 同样是主图或非主图时，按sort字段排序
-				if images[j].Sort < images[i].Sort {
-					images[i], images[j] = images[j], images[i]
-				}
-			}
-		}
+// NormalizeImageSort 按当前顺序将图片排序值重置为 0..n-1，主图排在最前
+// 排序值相同时保留原有先后顺序
+func (p *Product) NormalizeImageSort() {
+	sortImages(p.Images)
+	for i := range p.Images {
+		p.Images[i].Sort = i
 	}
+}
 
-	return images
+// sortImages 主图优先，其余按sort字段稳定排序
+func sortImages(images []ProductImage) {
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].IsMain != images[j].IsMain {
+			return images[i].IsMain
+		}
+		return images[i].Sort < images[j].Sort
+	})
 }
 
 // SetMainImage 设置主图（取消其他图片的主图状态）
@@ -173,14 +185,16 @@ func (p *Product) SetMainImage(url string) {
 // Color 颜色模型
 // @Description 商品颜色信息
 type Color struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
-	DeletedAt *time.Time `json:"deletedAt,omitempty" gorm:"index"`
-	Name      string     `json:"name" gorm:"type:varchar(50);uniqueIndex;not null" example:"黑色"` // 颜色名称
-	Code      string     `json:"code" gorm:"type:varchar(20);uniqueIndex" example:"BLACK"`       // 颜色代码
-	HexColor  string     `json:"hex_color" gorm:"type:varchar(7)" example:"#000000"`             // 十六进制颜色值
-	Products  []Product  `json:"products" gorm:"many2many:product_colors;"`                      // 关联的商品
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Name      string         `json:"name" gorm:"type:varchar(50);uniqueIndex;not null" example:"黑色"` // 颜色名称
+	Code      string         `json:"code" gorm:"type:varchar(20);uniqueIndex" example:"BLACK"`       // 颜色代码
+	HexColor  string         `json:"hex_color" gorm:"type:varchar(7)" example:"#000000"`             // 十六进制颜色值
+	Products  []Product      `json:"products" gorm:"many2many:product_colors;"`                      // 关联的商品
+
+	ProductCount *int64 `json:"product_count,omitempty" gorm:"-" example:"12"` // 使用该颜色的商品数量（不含已删除商品），仅颜色列表返回
 }
 
 // ProductColor 商品和颜色的多对多关联表
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ProductQueryHistory 商品查询历史（扫码/按编码查询商品时记录）
+type ProductQueryHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index:idx_query_history_user_created,priority:1;not null" example:"1"` // 查询人ID
+	ProductID uint      `json:"product_id" gorm:"index;not null" example:"1"`                                        // 商品ID
+	SKU       string    `json:"sku" gorm:"type:varchar(50)" example:"IPHONE14-128G-BLACK"`                           // 查询时的商品货号
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_query_history_user_created,priority:2,sort:desc"`         // 查询时间
+}
@@ -2,11 +2,9 @@ package product
 
 import (
 	"erp/internal/modules/product/handler"
-	"erp/internal/modules/product/model"
 	"erp/internal/modules/product/repository"
 	"erp/internal/modules/product/service"
 	sourceRepo "erp/internal/modules/source/repository"
-	tagsModel "erp/internal/modules/tags/model"
 	tagsRepo "erp/internal/modules/tags/repository"
 
 	"gorm.io/gorm"
@@ -17,13 +15,11 @@ type Module struct {
 	handler *handler.ProductHandler
 }
 
-func NewModule(db *gorm.DB) *Module {
-	// 自动迁移数据库表
-	db.AutoMigrate(&model.Product{}, &model.Color{}, &model.ProductColor{}, &tagsModel.Tag{}, &tagsModel.ProductTag{})
-
+// NewModule 创建模块，readDB 为只读副本连接（可为nil，此时只读查询使用主库）
+func NewModule(db, readDB *gorm.DB) *Module {
 	// 创建依赖
-	productRepo := repository.NewProductRepository(db)
-	sourceRepository := sourceRepo.NewSourceRepository(db)
+	productRepo := repository.NewProductRepository(db, readDB)
+	sourceRepository := sourceRepo.NewSourceRepository(db, readDB)
 	tagsRepository := tagsRepo.NewTagsRepository(db)
 	svc := service.NewProductService(productRepo, sourceRepository, tagsRepository)
 	h := handler.NewProductHandler(svc)
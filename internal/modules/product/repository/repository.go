@@ -3,8 +3,9 @@ package repository
 import (
 	"context"
 	"erp/internal/modules/product/model"
-	"log"
-	"strconv"
+	"errors"
+	"log/slog"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -20,44 +21,214 @@ type ProductListFilter struct {
 	IsDiscounted *bool    `form:"is_discounted"` // 是否优惠
 	IsEnabled    *bool    `form:"is_enabled"`    // 是否启用
 	ColorNames   []string `form:"colors"`        // 颜色名称列表
+	ColorMatch   string   `form:"color_match"`   // 颜色匹配方式: any（包含任一颜色，默认）, all（包含全部颜色）
 	ProductCode  string   `form:"product_code"`  // 商品编码搜索
 	ShippingTime string   `form:"shipping_time"` // 发货时间
 	OrderBy      string   `form:"order_by"`      // 排序字段: id, name, sku, product_code, price, discount_price, cost_price, is_discounted, is_enabled, shipping_time, created_at, updated_at
 	OrderDir     string   `form:"order_dir"`     // 排序方向: asc, desc
+	Include      string   `form:"include"`       // 预加载的关联数据（逗号分隔）: source, colors, tags, none，默认全部
 }
 
+// includeRelations include参数可选值对应的关联字段
+var includeRelations = map[string]string{
+	"source": "Source",
+	"colors": "Colors",
+	"tags":   "Tags",
+}
+
+// ParseInclude 解析include参数为需要预加载的关联字段，为空时预加载全部关联，none表示不预加载
+func ParseInclude(include string) ([]string, error) {
+	if strings.TrimSpace(include) == "" {
+		return []string{"Source", "Colors", "Tags"}, nil
+	}
+
+	var relations []string
+	for _, item := range strings.Split(include, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || item == "none" {
+			continue
+		}
+		relation, ok := includeRelations[item]
+		if !ok {
+			return nil, errors.New("include 只能为 source, colors, tags 或 none")
+		}
+		relations = append(relations, relation)
+	}
+	return uniqueStrings(relations), nil
+}
+
+// 颜色匹配方式
+const (
+	ColorMatchAny = "any"
+	ColorMatchAll = "all"
+)
+
+// 缺图商品筛选方式
+const (
+	MissingImagesAny  = "any"    // 没有图片或没有主图
+	MissingImagesNone = "images" // 没有任何图片
+	MissingImagesMain = "main"   // 有图片但没有主图
+)
+
+// 图片JSON列的判断条件（images 可能为NULL或非数组的历史数据，视为没有图片）
+const (
+	sqlNoImages = "(images IS NULL OR json_typeof(images) <> 'array' OR json_array_length(CASE WHEN json_typeof(images) = 'array' THEN images ELSE '[]'::json END) = 0)"
+	sqlNoMain   = "NOT EXISTS (SELECT 1 FROM json_array_elements(CASE WHEN json_typeof(images) = 'array' THEN images ELSE '[]'::json END) AS img WHERE (img->>'is_main')::boolean IS TRUE)"
+)
+
 type ProductRepository interface {
 	Create(ctx context.Context, product *model.Product) error
+	CreateWithTags(ctx context.Context, product *model.Product, tagIDs []uint) error
 	Update(ctx context.Context, product *model.Product) error
+	ReplaceTags(ctx context.Context, productID uint, tagIDs []uint) error
+	RemoveColors(ctx context.Context, productID uint, colorID *uint) (int64, error)
+	AssignSource(ctx context.Context, productIDs []uint, source *model.Source, updatedBy uint) ([]model.Product, error)
 	Delete(ctx context.Context, id uint) error
 	FindByID(ctx context.Context, id uint) (*model.Product, error)
 	List(ctx context.Context, page, pageSize int) ([]model.Product, int64, error)
 	ListWithFilter(ctx context.Context, filter ProductListFilter, page, pageSize int) ([]model.Product, int64, error)
+	ListRelated(ctx context.Context, productID uint, page, pageSize int) ([]model.Product, int64, error)
+	ListLowStock(ctx context.Context, threshold *int, page, pageSize int) ([]model.Product, int64, error)
+	ListMissingImages(ctx context.Context, missing string, page, pageSize int) ([]model.Product, int64, error)
 	FindBySKU(ctx context.Context, sku string) (*model.Product, error)
 	FindByProductCode(ctx context.Context, productCode string) (*model.Product, error)
+	GetByProductCode(ctx context.Context, productCode string) (*model.Product, error)
 	CreateColor(ctx context.Context, color *model.Color) error
 	UpdateColor(ctx context.Context, color *model.Color) error
 	DeleteColor(ctx context.Context, id uint) error
 	FindColorByID(ctx context.Context, id uint) (*model.Color, error)
 	FindColorByName(ctx context.Context, name string) (*model.Color, error)
+	FindDeletedColorByName(ctx context.Context, name string) (*model.Color, error)
+	RestoreColor(ctx context.Context, color *model.Color) error
 	ListColors(ctx context.Context, orderBy, orderDir string) ([]model.Color, error)
+	FindColorsByIDs(ctx context.Context, ids []uint) ([]model.Color, error)
+	CountProductsByColor(ctx context.Context) (map[uint]int64, error)
 	GetByCode(code string) (*model.Product, error)
+	CreateQueryHistory(ctx context.Context, history *model.ProductQueryHistory) error
+	ListQueryHistory(ctx context.Context, userID *uint, page, pageSize int) ([]model.ProductQueryHistory, int64, error)
 }
 
 type productRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	readDB *gorm.DB // 只读查询（列表、统计）使用的连接，未配置只读副本时与db相同
 }
 
-func NewProductRepository(db *gorm.DB) ProductRepository {
-	return &productRepository{db: db}
+func NewProductRepository(db, readDB *gorm.DB) ProductRepository {
+	if readDB == nil {
+		readDB = db
+	}
+	return &productRepository{db: db, readDB: readDB}
 }
 
 func (r *productRepository) Create(ctx context.Context, product *model.Product) error {
 	return r.db.WithContext(ctx).Create(product).Error
 }
 
+// CreateWithTags 在同一事务中创建商品（含颜色关联）及其标签关联
+func (r *productRepository) CreateWithTags(ctx context.Context, product *model.Product, tagIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Source", "Tags").Create(product).Error; err != nil {
+			return err
+		}
+
+		for _, tagID := range tagIDs {
+			if err := tx.Exec("INSERT INTO product_tags (product_id, tag_id, created_at) VALUES ($1, $2, NOW()) ON CONFLICT (product_id, tag_id) DO NOTHING", product.ID, tagID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReplaceTags 在事务中替换商品的标签集合（先清除再重新添加），任一标签不存在时整体回滚
+func (r *productRepository) ReplaceTags(ctx context.Context, productID uint, tagIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(tagIDs) > 0 {
+			var count int64
+			if err := tx.Model(&model.Tag{}).Where("id IN ? AND deleted_at IS NULL", tagIDs).Count(&count).Error; err != nil {
+				return err
+			}
+			if count != int64(len(tagIDs)) {
+				return errors.New("标签不存在")
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM product_tags WHERE product_id = ?", productID).Error; err != nil {
+			return err
+		}
+
+		for _, tagID := range tagIDs {
+			if err := tx.Exec("INSERT INTO product_tags (product_id, tag_id, created_at) VALUES (?, ?, NOW())", productID, tagID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveColors 删除商品的颜色关联，colorID 为nil时删除全部，返回删除的关联数量
+func (r *productRepository) RemoveColors(ctx context.Context, productID uint, colorID *uint) (int64, error) {
+	query := r.db.WithContext(ctx).Where("product_id = ?", productID)
+	if colorID != nil {
+		query = query.Where("color_id = ?", *colorID)
+	}
+	result := query.Delete(&model.ProductColor{})
+	return result.RowsAffected, result.Error
+}
+
+// AssignSource 在事务中批量设置商品货源并重新生成商品编码，返回受影响的商品
+func (r *productRepository) AssignSource(ctx context.Context, productIDs []uint, source *model.Source, updatedBy uint) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			return err
+		}
+		// 任一商品不存在时整体失败，不静默跳过
+		if len(products) != len(productIDs) {
+			return errors.New("商品不存在")
+		}
+
+		codes := make(map[string]bool, len(products))
+		for i := range products {
+			p := &products[i]
+			p.SourceID = &source.ID
+			p.Source = source
+			p.GenerateProductCode()
+
+			// 本批次内SKU相同的商品会生成相同的编码
+			if codes[p.ProductCode] {
+				return errors.New("商品编码已存在")
+			}
+			codes[p.ProductCode] = true
+
+			// 新编码不能与本批次之外的商品冲突
+			var count int64
+			if err := tx.Model(&model.Product{}).Where("product_code = ? AND id NOT IN ?", p.ProductCode, productIDs).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errors.New("商品编码已存在")
+			}
+
+			if err := tx.Model(&model.Product{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+				"source_id":    source.ID,
+				"product_code": p.ProductCode,
+				"updated_by":   updatedBy,
+				"version":      gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
 func (r *productRepository) Update(ctx context.Context, product *model.Product) error {
-	log.Printf("Repository: 开始更新商品 ID=%d", product.ID)
+	slog.DebugContext(ctx, "updating product", "product_id", product.ID)
 
 	// 使用事务来确保数据一致性
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -75,50 +246,68 @@ func (r *productRepository) Update(ctx context.Context, product *model.Product)
 			"images":         product.Images,
 			"shipping_time":  product.ShippingTime,
 			"updated_at":     product.UpdatedAt,
+			"updated_by":     product.UpdatedBy,
+			"stock":          product.Stock,
+			"reorder_level":  product.ReorderLevel,
+			"version":        gorm.Expr("version + 1"),
 		}
 
-		log.Printf("Repository: 更新商品基本信息")
-		if err := tx.Model(&model.Product{}).Where("id = ?", product.ID).Updates(updateData).Error; err != nil {
-			log.Printf("Repository: 更新商品基本信息失败: %v", err)
-			return err
+		// 乐观锁：仅当版本号未变化时更新
+		result := tx.Model(&model.Product{}).Where("id = ? AND version = ?", product.ID, product.Version).Updates(updateData)
+		if result.Error != nil {
+			slog.ErrorContext(ctx, "update product failed", "product_id", product.ID, "error", result.Error)
+			return result.Error
 		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&model.Product{}).Where("id = ?", product.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return errors.New("商品不存在")
+			}
+			return errors.New("数据已被他人修改，请刷新")
+		}
+		product.Version++
 
 		// 2. 处理颜色关联关系
-		log.Printf("Repository: 删除现有颜色关联")
 		// 先删除现有的颜色关联
 		if err := tx.Where("product_id = ?", product.ID).Delete(&model.ProductColor{}).Error; err != nil {
-			log.Printf("Repository: 删除颜色关联失败: %v", err)
+			slog.ErrorContext(ctx, "delete product colors failed", "product_id", product.ID, "error", err)
 			return err
 		}
 
 		// 如果有新的颜色，创建新的颜色关联
 		if len(product.Colors) > 0 {
-			log.Printf("Repository: 创建新的颜色关联，颜色数量=%d", len(product.Colors))
+			slog.DebugContext(ctx, "replacing product colors", "product_id", product.ID, "count", len(product.Colors))
 			var productColors []model.ProductColor
 			for _, color := range product.Colors {
 				productColors = append(productColors, model.ProductColor{
 					ProductID: product.ID,
 					ColorID:   color.ID,
 				})
-				log.Printf("Repository: 创建颜色关联 ProductID=%d, ColorID=%d", product.ID, color.ID)
 			}
 
 			if err := tx.Create(&productColors).Error; err != nil {
-				log.Printf("Repository: 创建颜色关联失败: %v", err)
+				slog.ErrorContext(ctx, "create product colors failed", "product_id", product.ID, "error", err)
 				return err
 			}
-			log.Printf("Repository: 颜色关联创建成功")
-		} else {
-			log.Printf("Repository: 没有新的颜色关联需要创建")
 		}
 
-		log.Printf("Repository: 商品更新完成")
+		slog.DebugContext(ctx, "product updated", "product_id", product.ID)
 		return nil
 	})
 }
 
 func (r *productRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.Product{}, id).Error
+	result := r.db.WithContext(ctx).Delete(&model.Product{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (r *productRepository) FindByID(ctx context.Context, id uint) (*model.Product, error) {
@@ -134,12 +323,12 @@ func (r *productRepository) List(ctx context.Context, page, pageSize int) ([]mod
 	var products []model.Product
 	var total int64
 
-	err := r.db.WithContext(ctx).Model(&model.Product{}).Count(&total).Error
+	err := r.readDB.WithContext(ctx).Model(&model.Product{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	err = r.db.WithContext(ctx).
+	err = r.readDB.WithContext(ctx).
 		Preload("Source").
 		Preload("Colors").
 		Preload("Tags").
@@ -151,16 +340,106 @@ func (r *productRepository) List(ctx context.Context, page, pageSize int) ([]mod
 	return products, total, err
 }
 
+// ListLowStock 查询库存不高于补货阈值的商品，按库存升序排列
+// 未设置补货阈值（reorder_level = 0）的商品使用threshold作为阈值，threshold为nil时忽略这些商品
+func (r *productRepository) ListLowStock(ctx context.Context, threshold *int, page, pageSize int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	query := r.readDB.WithContext(ctx).Model(&model.Product{})
+	if threshold != nil {
+		query = query.Where("(reorder_level > 0 AND stock <= reorder_level) OR (reorder_level = 0 AND stock <= ?)", *threshold)
+	} else {
+		query = query.Where("reorder_level > 0 AND stock <= reorder_level")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Source").
+		Preload("Colors").
+		Preload("Tags").
+		Order("stock ASC, id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&products).Error
+
+	return products, total, err
+}
+
+// ListMissingImages 查询缺少图片或主图的商品，missing 取值见 MissingImages* 常量
+func (r *productRepository) ListMissingImages(ctx context.Context, missing string, page, pageSize int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	query := r.readDB.WithContext(ctx).Model(&model.Product{})
+	switch missing {
+	case MissingImagesNone:
+		query = query.Where(sqlNoImages)
+	case MissingImagesMain:
+		query = query.Where("NOT " + sqlNoImages + " AND " + sqlNoMain)
+	default:
+		query = query.Where("(" + sqlNoImages + " OR " + sqlNoMain + ")")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Source").
+		Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&products).Error
+
+	return products, total, err
+}
+
+// ListRelated 查询与指定商品共享标签的其他已启用商品，按共享标签数量降序排列
+func (r *productRepository) ListRelated(ctx context.Context, productID uint, page, pageSize int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	// 统计每个商品与指定商品共享的标签数量
+	tagSubQuery := r.readDB.Table("product_tags").Select("tag_id").Where("product_id = ?", productID)
+	sharedQuery := r.readDB.Table("product_tags").
+		Select("product_id, COUNT(*) AS shared_tags").
+		Where("tag_id IN (?) AND product_id <> ?", tagSubQuery, productID).
+		Group("product_id")
+
+	query := r.readDB.WithContext(ctx).Model(&model.Product{}).
+		Joins("JOIN (?) AS related ON related.product_id = products.id", sharedQuery).
+		Where("products.is_enabled = ?", true)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Source").
+		Preload("Colors").
+		Preload("Tags").
+		Order("related.shared_tags DESC, products.id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&products).Error
+
+	return products, total, err
+}
+
 func (r *productRepository) ListWithFilter(ctx context.Context, filter ProductListFilter, page, pageSize int) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
 	// 构建查询条件
-	query := r.db.WithContext(ctx).Model(&model.Product{})
+	query := r.readDB.WithContext(ctx).Model(&model.Product{})
 
-	// 商品名称模糊搜索
+	// 商品名称模糊搜索（不区分大小写）
 	if filter.Name != "" {
-		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
+		query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
 	}
 
 	// SKU精确搜索
@@ -170,7 +449,7 @@ func (r *productRepository) ListWithFilter(ctx context.Context, filter ProductLi
 
 	// 商品编码模糊搜索
 	if filter.ProductCode != "" {
-		query = query.Where("product_code LIKE ?", "%"+filter.ProductCode+"%")
+		query = query.Where("product_code ILIKE ?", "%"+filter.ProductCode+"%")
 	}
 
 	// 货源筛选
@@ -198,14 +477,19 @@ func (r *productRepository) ListWithFilter(ctx context.Context, filter ProductLi
 
 	// 发货时间筛选
 	if filter.ShippingTime != "" {
-		query = query.Where("shipping_time LIKE ?", "%"+filter.ShippingTime+"%")
+		query = query.Where("shipping_time ILIKE ?", "%"+filter.ShippingTime+"%")
 	}
 
 	// 颜色筛选 - 使用子查询
 	if len(filter.ColorNames) > 0 {
-		colorSubQuery := r.db.Model(&model.Color{}).Select("id").Where("name IN ?", filter.ColorNames)
-		query = query.Where("id IN (?)",
-			r.db.Model(&model.ProductColor{}).Select("product_id").Where("color_id IN (?)", colorSubQuery))
+		colorNames := uniqueStrings(filter.ColorNames)
+		colorSubQuery := r.readDB.Model(&model.Color{}).Select("id").Where("name IN ?", colorNames)
+		productColorQuery := r.readDB.Model(&model.ProductColor{}).Select("product_id").Where("color_id IN (?)", colorSubQuery)
+		if filter.ColorMatch == ColorMatchAll {
+			// 要求商品关联的匹配颜色数量等于请求的颜色数量
+			productColorQuery = productColorQuery.Group("product_id").Having("COUNT(DISTINCT color_id) = ?", len(colorNames))
+		}
+		query = query.Where("id IN (?)", productColorQuery)
 	}
 
 	// 获取总数
@@ -247,53 +531,36 @@ func (r *productRepository) ListWithFilter(ctx context.Context, filter ProductLi
 		}
 	}
 
-	// 调试日志
-	println("DEBUG: OrderBy =", filter.OrderBy, "OrderDir =", filter.OrderDir)
-	println("DEBUG: Final orderBy =", orderBy, "orderDir =", orderDir)
-	println("DEBUG: SQL ORDER BY =", orderBy+" "+orderDir)
+	slog.DebugContext(ctx, "listing products",
+		"requested_order_by", filter.OrderBy,
+		"requested_order_dir", filter.OrderDir,
+		"order", orderBy+" "+orderDir,
+	)
 
-	// 验证字段是否存在
-	if filter.OrderBy != "" {
-		allowedFields := map[string]bool{
-			"id":             true,
-			"name":           true,
-			"sku":            true,
-			"product_code":   true,
-			"price":          true,
-			"discount_price": true,
-			"cost_price":     true,
-			"is_discounted":  true,
-			"is_enabled":     true,
-			"shipping_time":  true,
-			"created_at":     true,
-			"updated_at":     true,
-		}
-		println("DEBUG: Is", filter.OrderBy, "allowed?", allowedFields[filter.OrderBy])
+	// 执行查询，DEBUG 级别时输出SQL
+	debugDB := query
+	if slog.Default().Enabled(ctx, slog.LevelDebug) {
+		debugDB = query.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Info)})
 	}
 
-	// 打印完整的SQL查询字符串
-	sqlQuery := "SELECT * FROM products ORDER BY " + orderBy + " " + orderDir + " LIMIT " + strconv.Itoa(pageSize) + " OFFSET " + strconv.Itoa((page-1)*pageSize)
-	println("DEBUG: Expected SQL:", sqlQuery)
-
-	// 执行查询
-	// 临时启用调试模式查看SQL
-	debugDB := query.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Info)})
+	relations, err := ParseInclude(filter.Include)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, relation := range relations {
+		debugDB = debugDB.Preload(relation)
+	}
 
 	err = debugDB.
 		Order(orderBy + " " + orderDir).
-		Preload("Source").
-		Preload("Colors").
-		Preload("Tags").
 		Offset((page - 1) * pageSize).
 		Limit(pageSize).
 		Find(&products).Error
 
-	// 调试日志 - 查看生成的SQL
-	if err == nil {
-		println("DEBUG: Query executed successfully")
-		println("DEBUG: Found", len(products), "products")
+	if err != nil {
+		slog.ErrorContext(ctx, "list products failed", "error", err)
 	} else {
-		println("DEBUG: Query error:", err.Error())
+		slog.DebugContext(ctx, "products listed", "count", len(products), "total", total)
 	}
 
 	return products, total, err
@@ -301,7 +568,7 @@ func (r *productRepository) ListWithFilter(ctx context.Context, filter ProductLi
 
 func (r *productRepository) FindBySKU(ctx context.Context, sku string) (*model.Product, error) {
 	var product model.Product
-	err := r.db.WithContext(ctx).Where("sku = ?", sku).First(&product).Error
+	err := r.db.WithContext(ctx).Preload("Source").Preload("Colors").Preload("Tags").Where("sku = ?", sku).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -317,6 +584,16 @@ func (r *productRepository) FindByProductCode(ctx context.Context, productCode s
 	return &product, nil
 }
 
+// GetByProductCode 通过商品编码精确查找商品，并预加载货源、颜色和标签
+func (r *productRepository) GetByProductCode(ctx context.Context, productCode string) (*model.Product, error) {
+	var product model.Product
+	err := r.db.WithContext(ctx).Preload("Source").Preload("Colors").Preload("Tags").Where("product_code = ?", productCode).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 func (r *productRepository) CreateColor(ctx context.Context, color *model.Color) error {
 	return r.db.WithContext(ctx).Create(color).Error
 }
@@ -347,10 +624,56 @@ func (r *productRepository) FindColorByName(ctx context.Context, name string) (*
 	return &color, nil
 }
 
-func (r *productRepository) ListColors(ctx context.Context, orderBy, orderDir string) ([]model.Color, error) {
+// FindDeletedColorByName 查找已软删除的同名颜色（颜色名称有唯一索引，重新创建时需恢复该记录）
+func (r *productRepository) FindDeletedColorByName(ctx context.Context, name string) (*model.Color, error) {
+	var color model.Color
+	err := r.db.WithContext(ctx).Unscoped().Where("name = ? AND deleted_at IS NOT NULL", name).First(&color).Error
+	if err != nil {
+		return nil, err
+	}
+	return &color, nil
+}
+
+// RestoreColor 恢复已软删除的颜色并更新其信息
+func (r *productRepository) RestoreColor(ctx context.Context, color *model.Color) error {
+	color.DeletedAt = gorm.DeletedAt{}
+	return r.db.WithContext(ctx).Unscoped().Save(color).Error
+}
+
+// FindColorsByIDs 一次查询获取多个颜色（不保证顺序）
+func (r *productRepository) FindColorsByIDs(ctx context.Context, ids []uint) ([]model.Color, error) {
 	var colors []model.Color
+	if len(ids) == 0 {
+		return colors, nil
+	}
+	err := r.readDB.WithContext(ctx).Where("id IN ?", ids).Find(&colors).Error
+	return colors, err
+}
 
-	// 验证排序字段
+// CountProductsByColor 一次分组查询统计每个颜色关联的商品数量（不含已删除商品）
+func (r *productRepository) CountProductsByColor(ctx context.Context) (map[uint]int64, error) {
+	var rows []struct {
+		ColorID uint
+		Count   int64
+	}
+	err := r.readDB.WithContext(ctx).Model(&model.ProductColor{}).
+		Select("product_colors.color_id, COUNT(*) AS count").
+		Joins("JOIN products ON products.id = product_colors.product_id AND products.deleted_at IS NULL").
+		Group("product_colors.color_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ColorID] = row.Count
+	}
+	return counts, nil
+}
+
+// NormalizeColorOrder 将颜色列表的排序参数规范为白名单内的字段和 ASC/DESC，不合法的值使用默认排序（id ASC）
+func NormalizeColorOrder(orderBy, orderDir string) (sortField, sortDirection string) {
 	allowedFields := map[string]bool{
 		"id":         true,
 		"name":       true,
@@ -360,23 +683,26 @@ func (r *productRepository) ListColors(ctx context.Context, orderBy, orderDir st
 		"updated_at": true,
 	}
 
-	// 默认排序
-	sortField := "id"
-	sortDirection := "ASC"
+	sortField = "id"
+	sortDirection = "ASC"
 
-	if orderBy != "" && allowedFields[orderBy] {
+	if allowedFields[orderBy] {
 		sortField = orderBy
 	}
 
-	if orderDir != "" {
-		switch orderDir {
-		case "asc", "ASC":
-			sortDirection = "ASC"
-		case "desc", "DESC":
-			sortDirection = "DESC"
-		}
+	switch orderDir {
+	case "asc", "ASC":
+		sortDirection = "ASC"
+	case "desc", "DESC":
+		sortDirection = "DESC"
 	}
+	return sortField, sortDirection
+}
+
+func (r *productRepository) ListColors(ctx context.Context, orderBy, orderDir string) ([]model.Color, error) {
+	var colors []model.Color
 
+	sortField, sortDirection := NormalizeColorOrder(orderBy, orderDir)
 	err := r.db.WithContext(ctx).Order(sortField + " " + sortDirection).Find(&colors).Error
 	return colors, err
 }
@@ -389,3 +715,43 @@ func (r *productRepository) GetByCode(code string) (*model.Product, error) {
 	}
 	return &product, nil
 }
+
+// uniqueStrings 去除重复字符串，保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// CreateQueryHistory 记录一条商品查询历史
+func (r *productRepository) CreateQueryHistory(ctx context.Context, history *model.ProductQueryHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+// ListQueryHistory 分页获取商品查询历史（按时间倒序），userID 为nil时返回所有用户的记录
+func (r *productRepository) ListQueryHistory(ctx context.Context, userID *uint, page, pageSize int) ([]model.ProductQueryHistory, int64, error) {
+	var histories []model.ProductQueryHistory
+	var total int64
+
+	query := r.readDB.WithContext(ctx).Model(&model.ProductQueryHistory{})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&histories).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return histories, total, nil
+}
@@ -2,18 +2,27 @@ package service
 
 import (
 	"context"
+	auditModel "erp/internal/modules/audit/model"
 	"erp/internal/modules/product/model"
 	"erp/internal/modules/product/repository"
 	sourceRepo "erp/internal/modules/source/repository"
 	tagsRepo "erp/internal/modules/tags/repository"
+	webhookModel "erp/internal/modules/webhook/model"
+	"erp/pkg/audit"
+	"erp/pkg/auth"
+	"erp/pkg/webhook"
 	"errors"
-	"log"
+	"log/slog"
+	"sync"
 )
 
 type ProductService interface {
 	CreateProduct(ctx context.Context, product *model.Product, colorNames []string, tagIDs []uint) error
 	UpdateProduct(ctx context.Context, product *model.Product, colorNames []string, tagIDs []uint) error
 	DeleteProduct(ctx context.Context, id uint) error
+	AssignSource(ctx context.Context, productIDs []uint, sourceID uint) (int64, error)
+	SetProductTags(ctx context.Context, id uint, tagIDs []uint) (*model.Product, error)
+	RemoveProductColors(ctx context.Context, id uint, colorID *uint) (*model.Product, error)
 	GetProduct(ctx context.Context, id uint) (*model.Product, error)
 	ListProducts(ctx context.Context, page, pageSize int) ([]model.Product, int64, error)
 	ListProductsWithFilter(ctx context.Context, filter repository.ProductListFilter, page, pageSize int) ([]model.Product, int64, error)
@@ -22,14 +31,28 @@ type ProductService interface {
 	DeleteColor(ctx context.Context, id uint) error
 	GetColor(ctx context.Context, id uint) (*model.Color, error)
 	ListColors(ctx context.Context, orderBy, orderDir string) ([]model.Color, error)
+	GetColorsByIDs(ctx context.Context, ids []uint) ([]model.Color, error)
 	GetByCode(ctx context.Context, code string, userID uint) (*model.Product, error)
 	GetBySKU(ctx context.Context, sku string) (*model.Product, error)
+	GetByProductCode(ctx context.Context, productCode string, userID uint) (*model.Product, error)
+	ListQueryHistory(ctx context.Context, userID *uint, page, pageSize int) ([]model.ProductQueryHistory, int64, error)
+	MoveImage(ctx context.Context, id uint, url string, newPosition int) (*model.Product, error)
+	CloneProduct(ctx context.Context, id uint, newSKU string) (*model.Product, error)
+	ListRelatedProducts(ctx context.Context, id uint, page, pageSize int) ([]model.Product, int64, error)
+	ListLowStockProducts(ctx context.Context, threshold *int, page, pageSize int) ([]model.Product, int64, error)
+	ListProductsMissingImages(ctx context.Context, missing string, page, pageSize int) ([]model.Product, int64, error)
 }
 
 type productService struct {
 	repo       repository.ProductRepository
 	sourceRepo sourceRepo.SourceRepository
 	tagsRepo   *tagsRepo.TagsRepository
+
+	// 颜色很少变更，缓存颜色列表（按规范化后的排序方式区分），颜色增删改时失效
+	// colorCacheGen 每次失效时递增，查询期间发生失效的结果不写入缓存
+	colorCacheMu  sync.RWMutex
+	colorCache    map[string][]model.Color
+	colorCacheGen uint64
 }
 
 func NewProductService(repo repository.ProductRepository, sourceRepo sourceRepo.SourceRepository, tagsRepo *tagsRepo.TagsRepository) ProductService {
@@ -41,6 +64,10 @@ func NewProductService(repo repository.ProductRepository, sourceRepo sourceRepo.
 }
 
 func (s *productService) CreateProduct(ctx context.Context, product *model.Product, colorNames []string, tagIDs []uint) error {
+	// 记录创建人
+	product.CreatedBy = auth.UserIDFromContext(ctx)
+	product.UpdatedBy = product.CreatedBy
+
 	// 如果指定了货源ID，获取货源信息并生成商品编码
 	if product.SourceID != nil {
 		source, err := s.sourceRepo.FindByID(ctx, *product.SourceID)
@@ -94,19 +121,28 @@ func (s *productService) CreateProduct(ctx context.Context, product *model.Produ
 		}
 	}
 
+	audit.Record(ctx, auditModel.ActionCreate, "product", product.ID, map[string]interface{}{
+		"sku":  product.SKU,
+		"name": product.Name,
+	})
+
+	webhook.Dispatch(webhookModel.EventProductCreated, product)
 	return nil
 }
 
 func (s *productService) UpdateProduct(ctx context.Context, product *model.Product, colorNames []string, tagIDs []uint) error {
-	log.Printf("Service: 开始更新商品 ID=%d, 颜色名称=%v", product.ID, colorNames)
+	slog.DebugContext(ctx, "updating product", "product_id", product.ID, "colors", colorNames)
 
 	// 检查商品是否存在
-	_, err := s.repo.FindByID(ctx, product.ID)
+	existing, err := s.repo.FindByID(ctx, product.ID)
 	if err != nil {
-		log.Printf("Service: 商品不存在 ID=%d", product.ID)
+		slog.WarnContext(ctx, "product not found", "product_id", product.ID)
 		return err
 	}
 
+	// 记录修改人
+	product.UpdatedBy = auth.UserIDFromContext(ctx)
+
 	// 如果指定了货源ID，获取货源信息并生成商品编码
 	if product.SourceID != nil {
 		source, err := s.sourceRepo.FindByID(ctx, *product.SourceID)
@@ -131,20 +167,17 @@ func (s *productService) UpdateProduct(ctx context.Context, product *model.Produ
 		}
 	}
 
-	// 处理颜色
-	log.Printf("Service: 处理颜色关联")
-	colors, err := s.handleColors(ctx, colorNames)
-	if err != nil {
-		log.Printf("Service: 处理颜色失败: %v", err)
-		return err
-	}
-	product.Colors = colors
-	log.Printf("Service: 颜色处理完成，颜色数量=%d", len(colors))
-	for i, color := range colors {
-		log.Printf("Service: 颜色[%d] ID=%d, 名称=%s", i, color.ID, color.Name)
+	// 处理颜色，colorNames 为nil时保留商品原有颜色
+	if colorNames != nil {
+		colors, err := s.handleColors(ctx, colorNames)
+		if err != nil {
+			slog.ErrorContext(ctx, "resolve product colors failed", "product_id", product.ID, "error", err)
+			return err
+		}
+		product.Colors = colors
+		slog.DebugContext(ctx, "product colors resolved", "product_id", product.ID, "count", len(colors))
 	}
 
-	log.Printf("Service: 调用repository更新商品")
 	err = s.repo.Update(ctx, product)
 	if err != nil {
 		return err
@@ -169,11 +202,251 @@ func (s *productService) UpdateProduct(ctx context.Context, product *model.Produ
 		}
 	}
 
+	audit.Record(ctx, auditModel.ActionUpdate, "product", product.ID, map[string]interface{}{
+		"sku":  product.SKU,
+		"name": product.Name,
+	})
+
+	webhook.Dispatch(webhookModel.EventProductUpdated, product)
+	if product.Stock != existing.Stock {
+		webhook.Dispatch(webhookModel.EventProductStockChanged, map[string]interface{}{
+			"product_id": product.ID,
+			"sku":        product.SKU,
+			"old_stock":  existing.Stock,
+			"new_stock":  product.Stock,
+		})
+	}
+
 	return nil
 }
 
 func (s *productService) DeleteProduct(ctx context.Context, id uint) error {
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionDelete, "product", id, nil)
+	webhook.Dispatch(webhookModel.EventProductDeleted, map[string]interface{}{"product_id": id})
+	return nil
+}
+
+// ListLowStockProducts 获取库存不高于补货阈值的商品
+func (s *productService) ListLowStockProducts(ctx context.Context, threshold *int, page, pageSize int) ([]model.Product, int64, error) {
+	return s.repo.ListLowStock(ctx, threshold, page, pageSize)
+}
+
+// ListProductsMissingImages 查询缺少图片或主图的商品
+func (s *productService) ListProductsMissingImages(ctx context.Context, missing string, page, pageSize int) ([]model.Product, int64, error) {
+	return s.repo.ListMissingImages(ctx, missing, page, pageSize)
+}
+
+// ListRelatedProducts 获取与指定商品共享标签的相关商品
+func (s *productService) ListRelatedProducts(ctx context.Context, id uint, page, pageSize int) ([]model.Product, int64, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListRelated(ctx, id, page, pageSize)
+}
+
+// SetProductTags 替换商品的全部标签，返回更新后的商品
+func (s *productService) SetProductTags(ctx context.Context, id uint, tagIDs []uint) (*model.Product, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	tagIDs = uniqueIDs(tagIDs)
+	if err := s.repo.ReplaceTags(ctx, id, tagIDs); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, auditModel.ActionUpdate, "product", id, map[string]interface{}{"tag_ids": tagIDs})
+	webhook.Dispatch(webhookModel.EventProductUpdated, product)
+	return product, nil
+}
+
+// RemoveProductColors 移除商品的颜色关联，colorID 为nil时移除全部颜色，返回更新后的商品
+func (s *productService) RemoveProductColors(ctx context.Context, id uint, colorID *uint) (*model.Product, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.RemoveColors(ctx, id, colorID)
+	if err != nil {
+		return nil, err
+	}
+	if colorID != nil && removed == 0 {
+		return nil, errors.New("商品未关联该颜色")
+	}
+
+	product, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := map[string]interface{}{"removed_colors": removed}
+	if colorID != nil {
+		detail["color_id"] = *colorID
+	}
+	audit.Record(ctx, auditModel.ActionUpdate, "product", id, detail)
+	webhook.Dispatch(webhookModel.EventProductUpdated, product)
+	return product, nil
+}
+
+// AssignSource 批量为商品设置货源，并按新货源重新生成商品编码
+func (s *productService) AssignSource(ctx context.Context, productIDs []uint, sourceID uint) (int64, error) {
+	source, err := s.sourceRepo.FindByID(ctx, sourceID)
+	if err != nil {
+		return 0, errors.New("货源不存在")
+	}
+
+	products, err := s.repo.AssignSource(ctx, uniqueIDs(productIDs), &model.Source{
+		ID:     source.ID,
+		Name:   source.Name,
+		Code:   source.Code,
+		Status: source.Status,
+	}, auth.UserIDFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range products {
+		audit.Record(ctx, auditModel.ActionUpdate, "product", products[i].ID, map[string]interface{}{
+			"source_id":    sourceID,
+			"product_code": products[i].ProductCode,
+		})
+		webhook.Dispatch(webhookModel.EventProductUpdated, products[i])
+	}
+	return int64(len(products)), nil
+}
+
+// uniqueIDs 去除重复ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// CloneProduct 以新的SKU复制商品（名称、价格、图片、颜色、标签、发货时间），在同一事务中创建
+func (s *productService) CloneProduct(ctx context.Context, id uint, newSKU string) (*model.Product, error) {
+	original, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查新SKU是否已存在
+	existing, err := s.repo.FindBySKU(ctx, newSKU)
+	if err == nil && existing != nil {
+		return nil, errors.New("商品SKU已存在")
+	}
+
+	images := make(model.ProductImages, len(original.Images))
+	copy(images, original.Images)
+
+	clone := &model.Product{
+		Name:          original.Name + "副本",
+		SKU:           newSKU,
+		SourceID:      original.SourceID,
+		Source:        original.Source,
+		Price:         original.Price,
+		IsDiscounted:  original.IsDiscounted,
+		DiscountPrice: original.DiscountPrice,
+		CostPrice:     original.CostPrice,
+		Images:        images,
+		Colors:        original.Colors,
+		ShippingTime:  original.ShippingTime,
+		IsEnabled:     original.IsEnabled,
+		ReorderLevel:  original.ReorderLevel,
+		CreatedBy:     auth.UserIDFromContext(ctx),
+	}
+	clone.UpdatedBy = clone.CreatedBy
+
+	// 使用新SKU重新生成商品编码
+	clone.GenerateProductCode()
+	if clone.ProductCode != "" {
+		existing, err := s.repo.FindByProductCode(ctx, clone.ProductCode)
+		if err == nil && existing != nil {
+			return nil, errors.New("商品编码已存在")
+		}
+	}
+
+	tagIDs := make([]uint, 0, len(original.Tags))
+	for _, tag := range original.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	if err := s.repo.CreateWithTags(ctx, clone, tagIDs); err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, auditModel.ActionCreate, "product", clone.ID, map[string]interface{}{
+		"sku":         clone.SKU,
+		"name":        clone.Name,
+		"cloned_from": original.ID,
+	})
+
+	created, err := s.repo.FindByID(ctx, clone.ID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Dispatch(webhookModel.EventProductCreated, created)
+	return created, nil
+}
+
+// MoveImage 将图片移动到指定位置，并重新编排所有图片的排序值
+func (s *productService) MoveImage(ctx context.Context, id uint, url string, newPosition int) (*model.Product, error) {
+	product, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	images := product.GetSortedImages()
+	index := -1
+	for i, img := range images {
+		if img.URL == url {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, errors.New("指定的图片不存在")
+	}
+	if newPosition < 0 || newPosition >= len(images) {
+		return nil, errors.New("图片位置超出范围")
+	}
+
+	// 取出图片后插入到新位置
+	moved := images[index]
+	images = append(images[:index], images[index+1:]...)
+	images = append(images[:newPosition], append([]model.ProductImage{moved}, images[newPosition:]...)...)
+	for i := range images {
+		images[i].Sort = i
+	}
+	product.Images = images
+	product.UpdatedBy = auth.UserIDFromContext(ctx)
+
+	// 商品已预加载颜色，更新时保留原有颜色关联
+	if err := s.repo.Update(ctx, product); err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, auditModel.ActionUpdate, "product", product.ID, map[string]interface{}{
+		"move_image":   url,
+		"new_position": newPosition,
+	})
+	webhook.Dispatch(webhookModel.EventProductUpdated, product)
+
+	return product, nil
 }
 
 func (s *productService) GetProduct(ctx context.Context, id uint) (*model.Product, error) {
@@ -185,6 +458,7 @@ func (s *productService) ListProducts(ctx context.Context, page, pageSize int) (
 }
 
 func (s *productService) ListProductsWithFilter(ctx context.Context, filter repository.ProductListFilter, page, pageSize int) ([]model.Product, int64, error) {
+	// 不存在的颜色不报错：any 模式忽略该颜色，all 模式将无匹配结果
 	return s.repo.ListWithFilter(ctx, filter, page, pageSize)
 }
 
@@ -200,11 +474,27 @@ func (s *productService) CreateColor(ctx context.Context, name, code, hexColor s
 		code = s.generateColorCode(name)
 	}
 
+	// 同名颜色曾被删除时恢复原记录，避免唯一索引冲突
+	if deleted, err := s.repo.FindDeletedColorByName(ctx, name); err == nil && deleted != nil {
+		deleted.Code = code
+		deleted.HexColor = hexColor
+		if err := s.repo.RestoreColor(ctx, deleted); err != nil {
+			return nil, err
+		}
+
+		s.invalidateColorCache()
+		audit.Record(ctx, auditModel.ActionCreate, "color", deleted.ID, map[string]interface{}{"name": deleted.Name, "restored": true})
+		return deleted, nil
+	}
+
 	color := &model.Color{Name: name, Code: code, HexColor: hexColor}
 	err = s.repo.CreateColor(ctx, color)
 	if err != nil {
 		return nil, err
 	}
+
+	s.invalidateColorCache()
+	audit.Record(ctx, auditModel.ActionCreate, "color", color.ID, map[string]interface{}{"name": color.Name})
 	return color, nil
 }
 
@@ -225,19 +515,102 @@ func (s *productService) UpdateColor(ctx context.Context, id uint, name, code, h
 		return nil, err
 	}
 
+	s.invalidateColorCache()
+	audit.Record(ctx, auditModel.ActionUpdate, "color", existing.ID, map[string]interface{}{"name": existing.Name})
 	return existing, nil
 }
 
 func (s *productService) DeleteColor(ctx context.Context, id uint) error {
-	return s.repo.DeleteColor(ctx, id)
+	if err := s.repo.DeleteColor(ctx, id); err != nil {
+		return err
+	}
+
+	s.invalidateColorCache()
+	audit.Record(ctx, auditModel.ActionDelete, "color", id, nil)
+	return nil
 }
 
 func (s *productService) GetColor(ctx context.Context, id uint) (*model.Color, error) {
 	return s.repo.FindColorByID(ctx, id)
 }
 
+// ListColors 获取颜色列表（含每个颜色的商品数量）
+// 颜色列表走缓存；商品数量随商品变化，每次单独分组统计
 func (s *productService) ListColors(ctx context.Context, orderBy, orderDir string) ([]model.Color, error) {
-	return s.repo.ListColors(ctx, orderBy, orderDir)
+	colors, err := s.listColorsCached(ctx, orderBy, orderDir)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountProductsByColor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range colors {
+		count := counts[colors[i].ID]
+		colors[i].ProductCount = &count
+	}
+	return colors, nil
+}
+
+// listColorsCached 从缓存获取颜色列表，未命中时查询数据库并写入缓存，返回副本
+func (s *productService) listColorsCached(ctx context.Context, orderBy, orderDir string) ([]model.Color, error) {
+	// 使用白名单规范化后的排序方式作为缓存键，避免任意查询参数使缓存无限增长
+	sortField, sortDirection := repository.NormalizeColorOrder(orderBy, orderDir)
+	key := sortField + ":" + sortDirection
+
+	s.colorCacheMu.RLock()
+	cached, ok := s.colorCache[key]
+	gen := s.colorCacheGen
+	s.colorCacheMu.RUnlock()
+	if ok {
+		return append([]model.Color(nil), cached...), nil
+	}
+
+	colors, err := s.repo.ListColors(ctx, sortField, sortDirection)
+	if err != nil {
+		return nil, err
+	}
+
+	s.colorCacheMu.Lock()
+	if s.colorCacheGen == gen {
+		if s.colorCache == nil {
+			s.colorCache = make(map[string][]model.Color)
+		}
+		s.colorCache[key] = colors
+	}
+	s.colorCacheMu.Unlock()
+
+	return append([]model.Color(nil), colors...), nil
+}
+
+// invalidateColorCache 清空颜色列表缓存
+func (s *productService) invalidateColorCache() {
+	s.colorCacheMu.Lock()
+	s.colorCache = nil
+	s.colorCacheGen++
+	s.colorCacheMu.Unlock()
+}
+
+// GetColorsByIDs 批量获取颜色，按请求的ID顺序返回，不存在的ID会被跳过
+func (s *productService) GetColorsByIDs(ctx context.Context, ids []uint) ([]model.Color, error) {
+	colors, err := s.repo.FindColorsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]model.Color, len(colors))
+	for _, color := range colors {
+		byID[color.ID] = color
+	}
+
+	result := make([]model.Color, 0, len(ids))
+	for _, id := range ids {
+		if color, ok := byID[id]; ok {
+			result = append(result, color)
+		}
+	}
+	return result, nil
 }
 
 // handleColors 处理颜色列表，确保所有颜色都存在
@@ -290,6 +663,33 @@ func (s *productService) GetByCode(ctx context.Context, code string, userID uint
 	if err != nil {
 		return nil, err
 	}
+	s.recordQueryHistory(ctx, userID, product)
+	return product, nil
+}
+
+// recordQueryHistory 记录查询历史，失败不影响查询结果；服务账号（userID为0）不记录
+func (s *productService) recordQueryHistory(ctx context.Context, userID uint, product *model.Product) {
+	if userID == 0 {
+		return
+	}
+	history := &model.ProductQueryHistory{UserID: userID, ProductID: product.ID, SKU: product.SKU}
+	if err := s.repo.CreateQueryHistory(ctx, history); err != nil {
+		slog.WarnContext(ctx, "record product query history failed", "user_id", userID, "product_id", product.ID, "error", err)
+	}
+}
+
+// ListQueryHistory 分页获取商品查询历史，userID 为nil时返回所有用户的记录
+func (s *productService) ListQueryHistory(ctx context.Context, userID *uint, page, pageSize int) ([]model.ProductQueryHistory, int64, error) {
+	return s.repo.ListQueryHistory(ctx, userID, page, pageSize)
+}
+
+// GetByProductCode 通过商品编码精确获取商品（扫码枪场景）
+func (s *productService) GetByProductCode(ctx context.Context, productCode string, userID uint) (*model.Product, error) {
+	product, err := s.repo.GetByProductCode(ctx, productCode)
+	if err != nil {
+		return nil, err
+	}
+	s.recordQueryHistory(ctx, userID, product)
 	return product, nil
 }
 
@@ -27,6 +27,12 @@ type CreateSourceRequest struct {
 	Remark string `json:"remark" example:"优质货源"`
 }
 
+// UpdateSourceRequest 更新货源请求
+type UpdateSourceRequest struct {
+	CreateSourceRequest
+	Version int `json:"version" binding:"required,min=1" example:"1"` // 当前版本号（必填），用于检测并发修改
+}
+
 // @Summary 创建货源
 // @Description 创建新的货源信息
 // @Tags 货源管理
@@ -41,7 +47,7 @@ type CreateSourceRequest struct {
 func (h *SourceHandler) Create(c *gin.Context) {
 	var req CreateSourceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -67,11 +73,12 @@ func (h *SourceHandler) Create(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "货源ID"
-// @Param source body CreateSourceRequest true "货源信息"
+// @Param source body UpdateSourceRequest true "货源信息"
 // @Success 200 {object} response.Response{data=internal_modules_source_model.Source} "更新成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 404 {object} response.Response "货源不存在"
+// @Failure 409 {object} response.Response "数据已被他人修改，请刷新"
 // @Router /source/{id} [put]
 func (h *SourceHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -80,23 +87,26 @@ func (h *SourceHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var req CreateSourceRequest
+	var req UpdateSourceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
 	source := &model.Source{
-		ID:     uint(id),
-		Name:   req.Name,
-		Code:   req.Code,
-		Status: req.Status,
-		Remark: req.Remark,
+		ID:      uint(id),
+		Name:    req.Name,
+		Code:    req.Code,
+		Status:  req.Status,
+		Remark:  req.Remark,
+		Version: req.Version,
 	}
 
 	if err := h.svc.UpdateSource(c.Request.Context(), source); err != nil {
 		if err.Error() == "货源不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, err.Error()))
+		} else if err.Error() == "数据已被他人修改，请刷新" {
+			c.JSON(http.StatusConflict, response.LocalizedError(c, err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -127,7 +137,7 @@ func (h *SourceHandler) Delete(c *gin.Context) {
 
 	if err := h.svc.DeleteSource(c.Request.Context(), uint(id)); err != nil {
 		if err.Error() == "货源不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -159,7 +169,7 @@ func (h *SourceHandler) Get(c *gin.Context) {
 	source, err := h.svc.GetSource(c.Request.Context(), uint(id))
 	if err != nil {
 		if err.Error() == "货源不存在" {
-			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
 		}
@@ -181,15 +191,7 @@ func (h *SourceHandler) Get(c *gin.Context) {
 // @Failure 401 {object} response.Response "未授权"
 // @Router /source [get]
 func (h *SourceHandler) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 || pageSize > 100 {
-		pageSize = 10
-	}
+	page, pageSize := response.PageParams(c, "source", "page_size")
 
 	sources, total, err := h.svc.ListSources(c.Request.Context(), page, pageSize)
 	if err != nil {
@@ -225,3 +227,57 @@ func (h *SourceHandler) ListActive(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.Success("获取启用货源列表成功", sources))
 }
+
+// @Summary 获取货源商品数量
+// @Description 统计指定货源下的商品数量（不含已删除商品）
+// @Tags 货源管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "货源ID"
+// @Success 200 {object} response.Response{data=object{source_id=int,product_count=int64}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "货源不存在"
+// @Router /source/{id}/product-count [get]
+func (h *SourceHandler) ProductCount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的货源ID"))
+		return
+	}
+
+	count, err := h.svc.CountProducts(c.Request.Context(), uint(id))
+	if err != nil {
+		if err.Error() == "货源不存在" {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取货源商品数量成功", gin.H{
+		"source_id":     id,
+		"product_count": count,
+	}))
+}
+
+// @Summary 获取所有货源的商品数量
+// @Description 一次统计所有货源的商品数量（不含已删除商品），没有商品的货源返回0
+// @Tags 货源管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]internal_modules_source_model.SourceProductCount} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /source/product-counts [get]
+func (h *SourceHandler) ProductCounts(c *gin.Context) {
+	counts, err := h.svc.CountProductsBySource(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取货源商品数量成功", counts))
+}
@@ -15,4 +15,12 @@ type Source struct {
 	Code      string     `json:"code" gorm:"type:varchar(50);uniqueIndex;not null" example:"APPLE001"` // 货源编码
 	Status    int        `json:"status" gorm:"default:1" example:"1"`                                  // 状态：1-启用，0-禁用
 	Remark    string     `json:"remark" gorm:"type:text" example:"优质货源"`                               // 备注
+	Version   int        `json:"version" gorm:"default:1;not null" example:"1"`                        // 版本号（乐观锁）
+}
+
+// SourceProductCount 货源的商品数量
+type SourceProductCount struct {
+	SourceID     uint   `json:"source_id" example:"1"`      // 货源ID
+	Name         string `json:"name" example:"Apple官方旗舰店"`  // 货源名称
+	ProductCount int64  `json:"product_count" example:"42"` // 商品数量（不含已删除商品）
 }
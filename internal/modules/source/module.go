@@ -2,7 +2,6 @@ package source
 
 import (
 	"erp/internal/modules/source/handler"
-	"erp/internal/modules/source/model"
 	"erp/internal/modules/source/repository"
 	"erp/internal/modules/source/service"
 
@@ -14,12 +13,10 @@ type Module struct {
 	handler *handler.SourceHandler
 }
 
-func NewModule(db *gorm.DB) *Module {
-	// 自动迁移数据库表
-	db.AutoMigrate(&model.Source{})
-
+// NewModule 创建模块，readDB 为只读副本连接（可为nil，此时只读查询使用主库）
+func NewModule(db, readDB *gorm.DB) *Module {
 	// 创建依赖
-	repo := repository.NewSourceRepository(db)
+	repo := repository.NewSourceRepository(db, readDB)
 	svc := service.NewSourceService(repo)
 	h := handler.NewSourceHandler(svc)
 
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"erp/internal/modules/source/model"
+	"errors"
 
 	"gorm.io/gorm"
 )
@@ -15,14 +16,20 @@ type SourceRepository interface {
 	List(ctx context.Context, page, pageSize int) ([]model.Source, int64, error)
 	FindByCode(ctx context.Context, code string) (*model.Source, error)
 	ListByStatus(ctx context.Context, status int) ([]model.Source, error)
+	CountProducts(ctx context.Context, id uint) (int64, error)
+	CountProductsBySource(ctx context.Context) ([]model.SourceProductCount, error)
 }
 
 type sourceRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	readDB *gorm.DB // 只读查询（列表、统计）使用的连接，未配置只读副本时与db相同
 }
 
-func NewSourceRepository(db *gorm.DB) SourceRepository {
-	return &sourceRepository{db: db}
+func NewSourceRepository(db, readDB *gorm.DB) SourceRepository {
+	if readDB == nil {
+		readDB = db
+	}
+	return &sourceRepository{db: db, readDB: readDB}
 }
 
 func (r *sourceRepository) Create(ctx context.Context, source *model.Source) error {
@@ -30,7 +37,29 @@ func (r *sourceRepository) Create(ctx context.Context, source *model.Source) err
 }
 
 func (r *sourceRepository) Update(ctx context.Context, source *model.Source) error {
-	return r.db.WithContext(ctx).Save(source).Error
+	// 乐观锁：仅当版本号未变化时更新
+	result := r.db.WithContext(ctx).Model(&model.Source{}).Where("id = ? AND version = ?", source.ID, source.Version).Updates(map[string]interface{}{
+		"name":    source.Name,
+		"code":    source.Code,
+		"status":  source.Status,
+		"remark":  source.Remark,
+		"version": gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&model.Source{}).Where("id = ?", source.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("货源不存在")
+		}
+		return errors.New("数据已被他人修改，请刷新")
+	}
+	source.Version++
+	return nil
 }
 
 func (r *sourceRepository) Delete(ctx context.Context, id uint) error {
@@ -50,12 +79,12 @@ func (r *sourceRepository) List(ctx context.Context, page, pageSize int) ([]mode
 	var sources []model.Source
 	var total int64
 
-	err := r.db.WithContext(ctx).Model(&model.Source{}).Count(&total).Error
+	err := r.readDB.WithContext(ctx).Model(&model.Source{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	err = r.db.WithContext(ctx).
+	err = r.readDB.WithContext(ctx).
 		Offset((page - 1) * pageSize).
 		Limit(pageSize).
 		Find(&sources).Error
@@ -74,6 +103,25 @@ func (r *sourceRepository) FindByCode(ctx context.Context, code string) (*model.
 
 func (r *sourceRepository) ListByStatus(ctx context.Context, status int) ([]model.Source, error) {
 	var sources []model.Source
-	err := r.db.WithContext(ctx).Where("status = ?", status).Find(&sources).Error
+	err := r.readDB.WithContext(ctx).Where("status = ?", status).Find(&sources).Error
 	return sources, err
 }
+
+// CountProducts 统计货源下的商品数量（不含已删除商品）
+func (r *sourceRepository) CountProducts(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.readDB.WithContext(ctx).Table("products").Where("source_id = ? AND deleted_at IS NULL", id).Count(&count).Error
+	return count, err
+}
+
+// CountProductsBySource 一次分组查询统计所有货源的商品数量，没有商品的货源数量为0
+func (r *sourceRepository) CountProductsBySource(ctx context.Context) ([]model.SourceProductCount, error) {
+	var counts []model.SourceProductCount
+	err := r.readDB.WithContext(ctx).Model(&model.Source{}).
+		Select("sources.id AS source_id, sources.name, COUNT(products.id) AS product_count").
+		Joins("LEFT JOIN products ON products.source_id = sources.id AND products.deleted_at IS NULL").
+		Group("sources.id, sources.name").
+		Order("sources.id ASC").
+		Scan(&counts).Error
+	return counts, err
+}
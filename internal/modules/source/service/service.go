@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	auditModel "erp/internal/modules/audit/model"
 	"erp/internal/modules/source/model"
 	"erp/internal/modules/source/repository"
+	"erp/pkg/audit"
 	"errors"
 )
 
@@ -14,6 +16,8 @@ type SourceService interface {
 	GetSource(ctx context.Context, id uint) (*model.Source, error)
 	ListSources(ctx context.Context, page, pageSize int) ([]model.Source, int64, error)
 	ListActiveSource(ctx context.Context) ([]model.Source, error)
+	CountProducts(ctx context.Context, id uint) (int64, error)
+	CountProductsBySource(ctx context.Context) ([]model.SourceProductCount, error)
 }
 
 type sourceService struct {
@@ -31,7 +35,15 @@ func (s *sourceService) CreateSource(ctx context.Context, source *model.Source)
 		return errors.New("货源编码已存在")
 	}
 
-	return s.repo.Create(ctx, source)
+	if err := s.repo.Create(ctx, source); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionCreate, "source", source.ID, map[string]interface{}{
+		"code": source.Code,
+		"name": source.Name,
+	})
+	return nil
 }
 
 func (s *sourceService) UpdateSource(ctx context.Context, source *model.Source) error {
@@ -41,7 +53,15 @@ func (s *sourceService) UpdateSource(ctx context.Context, source *model.Source)
 		return errors.New("货源不存在")
 	}
 
-	return s.repo.Update(ctx, source)
+	if err := s.repo.Update(ctx, source); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionUpdate, "source", source.ID, map[string]interface{}{
+		"code": source.Code,
+		"name": source.Name,
+	})
+	return nil
 }
 
 func (s *sourceService) DeleteSource(ctx context.Context, id uint) error {
@@ -51,7 +71,12 @@ func (s *sourceService) DeleteSource(ctx context.Context, id uint) error {
 		return errors.New("货源不存在")
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionDelete, "source", id, nil)
+	return nil
 }
 
 func (s *sourceService) GetSource(ctx context.Context, id uint) (*model.Source, error) {
@@ -69,3 +94,14 @@ func (s *sourceService) ListSources(ctx context.Context, page, pageSize int) ([]
 func (s *sourceService) ListActiveSource(ctx context.Context) ([]model.Source, error) {
 	return s.repo.ListByStatus(ctx, 1)
 }
+
+func (s *sourceService) CountProducts(ctx context.Context, id uint) (int64, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return 0, errors.New("货源不存在")
+	}
+	return s.repo.CountProducts(ctx, id)
+}
+
+func (s *sourceService) CountProductsBySource(ctx context.Context) ([]model.SourceProductCount, error) {
+	return s.repo.CountProductsBySource(ctx)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -9,6 +10,7 @@ import (
 	"erp/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateTagRequest 创建标签请求
@@ -27,6 +29,12 @@ type UpdateTagRequest struct {
 	IsEnabled   *bool   `json:"is_enabled,omitempty" example:"true"`
 }
 
+// MergeTagsRequest 合并标签请求
+type MergeTagsRequest struct {
+	SourceID uint `json:"source_id" binding:"required" example:"2"` // 被合并（将删除）的标签ID
+	TargetID uint `json:"target_id" binding:"required" example:"1"` // 保留的目标标签ID
+}
+
 type TagsHandler struct {
 	service *service.TagsService
 }
@@ -47,7 +55,7 @@ func NewTagsHandler(service *service.TagsService) *TagsHandler {
 func (h *TagsHandler) CreateTag(c *gin.Context) {
 	var req CreateTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -58,14 +66,26 @@ func (h *TagsHandler) CreateTag(c *gin.Context) {
 		IsEnabled:   req.IsEnabled,
 	}
 
-	if err := h.service.CreateTag(tag); err != nil {
-		c.JSON(http.StatusInternalServerError, response.Error("创建标签失败: "+err.Error()))
+	if err := h.service.CreateTag(c.Request.Context(), tag); err != nil {
+		respondColorError(c, err, "创建标签失败: ")
 		return
 	}
 
 	c.JSON(http.StatusOK, response.Success("标签创建成功", tag))
 }
 
+// respondColorError 颜色校验错误返回400/409，其他错误返回500
+func respondColorError(c *gin.Context, err error, prefix string) {
+	switch err.Error() {
+	case "颜色格式错误，应为#RRGGBB":
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+	case "颜色已被其他启用的标签使用":
+		c.JSON(http.StatusConflict, response.Error(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, response.Error(prefix+err.Error()))
+	}
+}
+
 // GetTagByID 根据ID获取标签
 // @Summary 获取标签详情
 // @Description 根据ID获取标签详细信息
@@ -85,7 +105,7 @@ func (h *TagsHandler) GetTagByID(c *gin.Context) {
 
 	tag, err := h.service.GetTagByID(uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.Error("标签不存在"))
+		c.JSON(http.StatusNotFound, response.LocalizedError(c, "标签不存在"))
 		return
 	}
 
@@ -148,14 +168,14 @@ func (h *TagsHandler) UpdateTag(c *gin.Context) {
 
 	var req UpdateTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
 	// 获取现有标签
 	existingTag, err := h.service.GetTagByID(uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.Error("标签不存在"))
+		c.JSON(http.StatusNotFound, response.LocalizedError(c, "标签不存在"))
 		return
 	}
 
@@ -173,8 +193,8 @@ func (h *TagsHandler) UpdateTag(c *gin.Context) {
 		existingTag.IsEnabled = *req.IsEnabled
 	}
 
-	if err := h.service.UpdateTag(existingTag); err != nil {
-		c.JSON(http.StatusInternalServerError, response.Error("更新标签失败: "+err.Error()))
+	if err := h.service.UpdateTag(c.Request.Context(), existingTag, req.Color != nil); err != nil {
+		respondColorError(c, err, "更新标签失败: ")
 		return
 	}
 
@@ -183,7 +203,7 @@ func (h *TagsHandler) UpdateTag(c *gin.Context) {
 
 // DeleteTag 删除标签
 // @Summary 删除标签
-// @Description 删除指定标签
+// @Description 删除指定标签（软删除，同时移除其商品关联）
 // @Tags 标签管理
 // @Accept json
 // @Produce json
@@ -198,7 +218,11 @@ func (h *TagsHandler) DeleteTag(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteTag(uint(id)); err != nil {
+	if err := h.service.DeleteTag(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "标签不存在"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.Error("删除标签失败"))
 		return
 	}
@@ -206,6 +230,40 @@ func (h *TagsHandler) DeleteTag(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Success("标签删除成功", nil))
 }
 
+// MergeTags 合并标签
+// @Summary 合并标签
+// @Description 将源标签的商品关联转移到目标标签（已关联目标标签的商品跳过），然后删除源标签
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Param request body MergeTagsRequest true "源标签和目标标签"
+// @Success 200 {object} response.Response{data=object{moved=int64}}
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Router /api/tags/merge [post]
+func (h *TagsHandler) MergeTags(c *gin.Context) {
+	var req MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	moved, err := h.service.MergeTags(c.Request.Context(), req.SourceID, req.TargetID)
+	if err != nil {
+		switch err.Error() {
+		case "不能将标签合并到自身":
+			c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		case "源标签不存在", "目标标签不存在":
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.Error("合并标签失败: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("标签合并成功", gin.H{"moved": moved}))
+}
+
 // GetProductsByTag 获取标签下的所有产品
 // @Summary 获取标签下的产品
 // @Description 获取指定标签下的所有产品
@@ -258,6 +316,10 @@ func (h *TagsHandler) AddProductToTag(c *gin.Context) {
 	}
 
 	if err := h.service.AddProductToTag(uint(id), uint(productID)); err != nil {
+		if err.Error() == "标签不存在" {
+			c.JSON(http.StatusNotFound, response.LocalizedError(c, "标签不存在"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.Error("添加产品到标签失败"))
 		return
 	}
@@ -12,11 +12,11 @@ type Tag struct {
 	CreatedAt   time.Time       `json:"createdAt"`
 	UpdatedAt   time.Time       `json:"updatedAt"`
 	DeletedAt   *time.Time      `json:"deletedAt,omitempty" gorm:"index"`
-	Name        string          `json:"name" gorm:"type:varchar(50);uniqueIndex;not null" example:"热销"` // 标签名称
-	Description string          `json:"description" gorm:"type:varchar(200)" example:"热销商品标签"`          // 标签描述
-	Color       string          `json:"color" gorm:"type:varchar(7)" example:"#FF6B6B"`                 // 标签颜色
-	IsEnabled   bool            `json:"is_enabled" gorm:"default:true" example:"true"`                  // 是否启用
-	Products    []model.Product `json:"products" gorm:"many2many:product_tags;"`                        // 关联的商品
+	Name        string          `json:"name" gorm:"type:varchar(50);not null" example:"热销"`    // 标签名称（未删除的标签内唯一）
+	Description string          `json:"description" gorm:"type:varchar(200)" example:"热销商品标签"` // 标签描述
+	Color       string          `json:"color" gorm:"type:varchar(7)" example:"#FF6B6B"`        // 标签颜色
+	IsEnabled   bool            `json:"is_enabled" gorm:"default:true" example:"true"`         // 是否启用
+	Products    []model.Product `json:"products" gorm:"many2many:product_tags;"`               // 关联的商品
 }
 
 // ProductTag 商品和标签的多对多关联表
@@ -2,7 +2,6 @@ package tags
 
 import (
 	"erp/internal/modules/tags/handler"
-	"erp/internal/modules/tags/model"
 	"erp/internal/modules/tags/repository"
 	"erp/internal/modules/tags/service"
 
@@ -15,9 +14,6 @@ type Module struct {
 }
 
 func NewModule(db *gorm.DB) *Module {
-	// 自动迁移数据库表
-	db.AutoMigrate(&model.Tag{}, &model.ProductTag{})
-
 	// 创建依赖
 	repo := repository.NewTagsRepository(db)
 	svc := service.NewTagsService(repo)
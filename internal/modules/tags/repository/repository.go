@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	productModel "erp/internal/modules/product/model"
 	"erp/internal/modules/tags/model"
 
@@ -15,6 +17,11 @@ func NewTagsRepository(db *gorm.DB) *TagsRepository {
 	return &TagsRepository{db: db}
 }
 
+// notDeleted 排除已软删除（如被合并）的标签；Tag.DeletedAt 不是 gorm.DeletedAt，需手动过滤
+func notDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where("tags.deleted_at IS NULL")
+}
+
 // Create 创建标签
 func (r *TagsRepository) Create(tag *model.Tag) error {
 	return r.db.Create(tag).Error
@@ -23,7 +30,7 @@ func (r *TagsRepository) Create(tag *model.Tag) error {
 // GetByID 根据ID获取标签
 func (r *TagsRepository) GetByID(id uint) (*model.Tag, error) {
 	var tag model.Tag
-	err := r.db.Preload("Products").First(&tag, id).Error
+	err := r.db.Scopes(notDeleted).Preload("Products").First(&tag, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -33,14 +40,14 @@ func (r *TagsRepository) GetByID(id uint) (*model.Tag, error) {
 // GetAll 获取所有标签
 func (r *TagsRepository) GetAll() ([]model.Tag, error) {
 	var tags []model.Tag
-	err := r.db.Preload("Products").Find(&tags).Error
+	err := r.db.Scopes(notDeleted).Preload("Products").Find(&tags).Error
 	return tags, err
 }
 
 // GetEnabled 获取所有启用的标签
 func (r *TagsRepository) GetEnabled() ([]model.Tag, error) {
 	var tags []model.Tag
-	err := r.db.Where("is_enabled = ?", true).Preload("Products").Find(&tags).Error
+	err := r.db.Scopes(notDeleted).Where("is_enabled = ?", true).Preload("Products").Find(&tags).Error
 	return tags, err
 }
 
@@ -49,15 +56,59 @@ func (r *TagsRepository) Update(tag *model.Tag) error {
 	return r.db.Save(tag).Error
 }
 
-// Delete 删除标签
+// Delete 软删除标签并移除其商品关联，与合并后的源标签处理方式一致；标签不存在或已删除时返回 gorm.ErrRecordNotFound
 func (r *TagsRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Tag{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Tag{}).Scopes(notDeleted).Where("id = ?", id).Update("deleted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Exec("DELETE FROM product_tags WHERE tag_id = $1", id).Error
+	})
+}
+
+// Exists 判断标签是否存在（不含已软删除的标签）
+func (r *TagsRepository) Exists(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Tag{}).Scopes(notDeleted).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// Merge 将源标签的商品关联转移到目标标签（跳过已关联目标标签的商品）并软删除源标签，返回转移的关联数量
+func (r *TagsRepository) Merge(sourceID, targetID uint) (int64, error) {
+	var moved int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("INSERT INTO product_tags (product_id, tag_id, created_at) SELECT product_id, $1, NOW() FROM product_tags WHERE tag_id = $2 ON CONFLICT (product_id, tag_id) DO NOTHING", targetID, sourceID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		if err := tx.Exec("DELETE FROM product_tags WHERE tag_id = $1", sourceID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Tag{}).Where("id = ?", sourceID).Update("deleted_at", time.Now()).Error
+	})
+	return moved, err
+}
+
+// FindEnabledByColor 查找使用指定颜色的其他启用标签
+func (r *TagsRepository) FindEnabledByColor(color string, excludeID uint) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.db.Scopes(notDeleted).Where("UPPER(color) = ? AND is_enabled = ? AND id <> ?", color, true, excludeID).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
 }
 
 // GetByName 根据名称获取标签
 func (r *TagsRepository) GetByName(name string) (*model.Tag, error) {
 	var tag model.Tag
-	err := r.db.Where("name = ?", name).First(&tag).Error
+	err := r.db.Scopes(notDeleted).Where("name = ?", name).First(&tag).Error
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +130,9 @@ func (r *TagsRepository) GetProductsByTag(tagID uint) ([]productModel.Product, e
 	var products []productModel.Product
 	err := r.db.Table("products").
 		Joins("JOIN product_tags ON products.id = product_tags.product_id").
+		Joins("JOIN tags ON tags.id = product_tags.tag_id").
 		Where("product_tags.tag_id = ?", tagID).
+		Scopes(notDeleted).
 		Find(&products).Error
 	return products, err
 }
@@ -90,6 +143,7 @@ func (r *TagsRepository) GetTagsByProduct(productID uint) ([]model.Tag, error) {
 	err := r.db.Table("tags").
 		Joins("JOIN product_tags ON tags.id = product_tags.tag_id").
 		Where("product_tags.product_id = ?", productID).
+		Scopes(notDeleted).
 		Find(&tags).Error
 	return tags, err
 }
@@ -1,11 +1,23 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"erp/config"
+	auditModel "erp/internal/modules/audit/model"
 	productModel "erp/internal/modules/product/model"
 	"erp/internal/modules/tags/model"
 	"erp/internal/modules/tags/repository"
+	"erp/pkg/audit"
 )
 
+// colorPattern 标签颜色格式 #RRGGBB
+var colorPattern = regexp.MustCompile(`^#[0-9A-F]{6}$`)
+
 type TagsService struct {
 	repo *repository.TagsRepository
 }
@@ -15,8 +27,16 @@ func NewTagsService(repo *repository.TagsRepository) *TagsService {
 }
 
 // CreateTag 创建标签
-func (s *TagsService) CreateTag(tag *model.Tag) error {
-	return s.repo.Create(tag)
+func (s *TagsService) CreateTag(ctx context.Context, tag *model.Tag) error {
+	if err := s.normalizeColor(tag); err != nil {
+		return err
+	}
+	if err := s.repo.Create(tag); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionCreate, "tag", tag.ID, map[string]interface{}{"name": tag.Name})
+	return nil
 }
 
 // GetTagByID 根据ID获取标签
@@ -34,14 +54,72 @@ func (s *TagsService) GetEnabledTags() ([]model.Tag, error) {
 	return s.repo.GetEnabled()
 }
 
-// UpdateTag 更新标签
-func (s *TagsService) UpdateTag(tag *model.Tag) error {
-	return s.repo.Update(tag)
+// UpdateTag 更新标签，仅在请求修改了颜色时校验颜色，避免历史数据中格式不规范或重复的颜色阻止其他字段的修改
+func (s *TagsService) UpdateTag(ctx context.Context, tag *model.Tag, colorChanged bool) error {
+	if colorChanged {
+		if err := s.normalizeColor(tag); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.Update(tag); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionUpdate, "tag", tag.ID, map[string]interface{}{"name": tag.Name})
+	return nil
+}
+
+// normalizeColor 校验标签颜色格式并转为大写，检查是否与其他启用标签颜色重复
+func (s *TagsService) normalizeColor(tag *model.Tag) error {
+	tag.Color = strings.ToUpper(strings.TrimSpace(tag.Color))
+	if !colorPattern.MatchString(tag.Color) {
+		return errors.New("颜色格式错误，应为#RRGGBB")
+	}
+
+	if existing, err := s.repo.FindEnabledByColor(tag.Color, tag.ID); err == nil {
+		if config.AppConfig.TagRejectDuplicateColor {
+			return errors.New("颜色已被其他启用的标签使用")
+		}
+		slog.Warn("tag color duplicates another enabled tag", "tag", tag.Name, "color", tag.Color, "existing_tag", existing.Name)
+	}
+	return nil
 }
 
 // DeleteTag 删除标签
-func (s *TagsService) DeleteTag(id uint) error {
-	return s.repo.Delete(id)
+func (s *TagsService) DeleteTag(ctx context.Context, id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionDelete, "tag", id, nil)
+	return nil
+}
+
+// MergeTags 将源标签合并到目标标签，返回转移的商品关联数量
+func (s *TagsService) MergeTags(ctx context.Context, sourceID, targetID uint) (int64, error) {
+	if sourceID == targetID {
+		return 0, errors.New("不能将标签合并到自身")
+	}
+	if exists, err := s.repo.Exists(sourceID); err != nil {
+		return 0, err
+	} else if !exists {
+		return 0, errors.New("源标签不存在")
+	}
+	if exists, err := s.repo.Exists(targetID); err != nil {
+		return 0, err
+	} else if !exists {
+		return 0, errors.New("目标标签不存在")
+	}
+	moved, err := s.repo.Merge(sourceID, targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	audit.Record(ctx, auditModel.ActionDelete, "tag", sourceID, map[string]interface{}{
+		"merged_into": targetID,
+		"moved":       moved,
+	})
+	return moved, nil
 }
 
 // GetTagByName 根据名称获取标签
@@ -49,8 +127,13 @@ func (s *TagsService) GetTagByName(name string) (*model.Tag, error) {
 	return s.repo.GetByName(name)
 }
 
-// AddProductToTag 为标签添加产品
+// AddProductToTag 为标签添加产品，不允许关联到已删除（含已合并）的标签
 func (s *TagsService) AddProductToTag(tagID, productID uint) error {
+	if exists, err := s.repo.Exists(tagID); err != nil {
+		return err
+	} else if !exists {
+		return errors.New("标签不存在")
+	}
 	return s.repo.AddProductToTag(tagID, productID)
 }
 
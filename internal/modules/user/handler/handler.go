@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"encoding/csv"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"erp/internal/modules/user/model"
 	"erp/internal/modules/user/service"
@@ -36,7 +39,7 @@ func NewHandler(service *service.Service) *Handler {
 func (h *Handler) Register(c *gin.Context) {
 	var req model.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -51,7 +54,7 @@ func (h *Handler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary 用户登录
-// @Description 用户登录并获取JWT令牌
+// @Description 用户登录并获取JWT令牌。若返回的 user.must_change_password 为 true，需先调用修改密码接口，其他接口将返回403
 // @Tags User
 // @Accept json
 // @Produce json
@@ -64,7 +67,7 @@ func (h *Handler) Register(c *gin.Context) {
 func (h *Handler) Login(c *gin.Context) {
 	var req model.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -116,7 +119,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	var req model.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -146,7 +149,7 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	var req model.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -168,22 +171,26 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param limit query int false "每页数量" default(10)
+// @Param not_logged_in_since query string false "只返回该日期之后未登录过的用户（含从未登录），格式 2006-01-02"
 // @Success 200 {object} response.Response{data=model.UserListResponse} "获取成功"
+// @Failure 400 {object} response.Response{error=string} "请求参数错误"
 // @Failure 401 {object} response.Response{error=string} "未授权"
 // @Failure 403 {object} response.Response{error=string} "权限不足"
 // @Router /user/admin/users [get]
 func (h *Handler) GetUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	page, limit := response.PageParams(c, "user", "limit")
+
+	var notLoggedInSince *time.Time
+	if since := c.Query("not_logged_in_since"); since != "" {
+		t, err := time.ParseInLocation("2006-01-02", since, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error("not_logged_in_since 格式错误，应为 2006-01-02"))
+			return
+		}
+		notLoggedInSince = &t
 	}
 
-	users, err := h.service.GetUsers(c, page, limit)
+	users, err := h.service.GetUsers(c, page, limit, notLoggedInSince)
 	if err != nil {
 		response.HandleError(c, err)
 		return
@@ -192,6 +199,64 @@ func (h *Handler) GetUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Success("获取成功", users))
 }
 
+// ExportUsers godoc
+// @Summary 导出用户列表
+// @Description 以CSV格式导出全部用户（用户名、邮箱、角色、是否启用、创建时间），不包含密码（需要管理员权限）
+// @Tags Admin
+// @Produce text/csv
+// @Security BearerAuth
+// @Success 200 {file} file "CSV文件"
+// @Failure 401 {object} response.Response{error=string} "未授权"
+// @Failure 403 {object} response.Response{error=string} "权限不足"
+// @Router /user/admin/users/export [get]
+func (h *Handler) ExportUsers(c *gin.Context) {
+	filename := "users_" + time.Now().Format("20060102_150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	// 写入UTF-8 BOM，便于Excel正确识别中文
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "username", "email", "role", "is_active", "created_at"})
+
+	err := h.service.ExportUsers(c.Request.Context(), func(users []model.Response) error {
+		for _, u := range users {
+			if err := w.Write([]string{
+				strconv.FormatUint(uint64(u.ID), 10),
+				u.Username,
+				u.Email,
+				u.Role,
+				strconv.FormatBool(u.IsActive),
+				u.CreatedAt.Format(time.RFC3339),
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		c.Writer.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "export users failed", "error", err)
+		abortStream(c)
+		return
+	}
+	w.Flush()
+}
+
+// abortStream 流式响应已开始后出错时直接关闭连接，不发送结束分块，使客户端看到下载不完整，而不是一个被截断却看似正常的文件
+// gin.Recovery 会吞掉 panic(http.ErrAbortHandler) 并正常结束响应，因此这里劫持底层连接后关闭
+func abortStream(c *gin.Context) {
+	c.Abort()
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "abort streaming response failed", "error", err)
+		return
+	}
+	conn.Close()
+}
+
 // AdminCreateUser godoc
 // @Summary 管理员创建用户
 // @Description 管理员创建新用户账户
@@ -210,7 +275,7 @@ func (h *Handler) GetUsers(c *gin.Context) {
 func (h *Handler) AdminCreateUser(c *gin.Context) {
 	var req model.AdminCreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -248,7 +313,7 @@ func (h *Handler) AdminUpdateUser(c *gin.Context) {
 
 	var req model.AdminUpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -286,7 +351,7 @@ func (h *Handler) AdminResetUserPassword(c *gin.Context) {
 
 	var req model.AdminResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.Error("请求参数错误: "+err.Error()))
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
 		return
 	}
 
@@ -8,18 +8,21 @@ import (
 
 // User 用户模型
 type User struct {
-	ID              uint           `json:"id" gorm:"primaryKey"`
-	Username        string         `json:"username" gorm:"not null;index"` // 🔥 移除uniqueIndex，改为普通index
-	Email           string         `json:"email" gorm:"not null;index"`    // 🔥 移除uniqueIndex，改为普通index
-	Password        string         `json:"-" gorm:"not null"`              // 密码不返回给前端
-	PasswordVersion uint           `json:"-" gorm:"default:1"`             // 密码版本，用于使旧token失效
-	Role            string         `json:"role" gorm:"default:'user'"`
-	IsActive        bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	Username           string         `json:"username" gorm:"not null;index"` // 🔥 移除uniqueIndex，改为普通index
+	Email              string         `json:"email" gorm:"not null;index"`    // 🔥 移除uniqueIndex，改为普通index
+	Password           string         `json:"-" gorm:"not null"`              // 密码不返回给前端
+	PasswordVersion    uint           `json:"-" gorm:"default:1"`             // 密码版本，用于使旧token失效
+	Role               string         `json:"role" gorm:"default:'user'"`
+	IsActive           bool           `json:"is_active" gorm:"default:true"`
+	MustChangePassword bool           `json:"must_change_password" gorm:"default:false"` // 是否需要在下次登录后强制修改密码
+	LastLoginAt        *time.Time     `json:"last_login_at"`                             // 最后登录时间，从未登录为nil
+	LastLoginIP        string         `json:"last_login_ip" gorm:"type:varchar(64)"`     // 最后登录IP
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
 
-	// 🔥 唯一索引将在数据库迁移中手动创建为条件索引，只对未删除的记录生效
+	// 🔥 唯一索引在数据库迁移中创建为条件索引，只对未删除的记录生效（见 pkg/database/indexes.go）
 }
 
 // RegisterRequest 注册请求结构
@@ -37,12 +40,14 @@ type LoginRequest struct {
 
 // Response 用户响应结构
 type Response struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                 uint       `json:"id"`
+	Username           string     `json:"username"`
+	Email              string     `json:"email"`
+	Role               string     `json:"role"`
+	IsActive           bool       `json:"is_active"`
+	MustChangePassword bool       `json:"must_change_password"` // 为true时前端应跳转到修改密码页面
+	LastLoginAt        *time.Time `json:"last_login_at"`        // 最后登录时间，从未登录为null
+	CreatedAt          time.Time  `json:"created_at"`
 }
 
 // UpdateProfileRequest 更新资料请求结构
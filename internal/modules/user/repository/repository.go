@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"erp/internal/modules/user/model"
 
@@ -79,24 +80,55 @@ func (r *Repository) ExistsByEmailAndNotID(ctx context.Context, email string, id
 	return count > 0
 }
 
-// FindWithPagination 分页查找用户
-func (r *Repository) FindWithPagination(ctx context.Context, offset, limit int) ([]model.User, int64, error) {
+// Count 统计用户数量（不包括已删除的用户）
+func (r *Repository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).Count(&count).Error
+	return count, err
+}
+
+// FindWithPagination 分页查找用户，notLoggedInSince 不为nil时只返回该时间之后未登录过的用户
+func (r *Repository) FindWithPagination(ctx context.Context, offset, limit int, notLoggedInSince *time.Time) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
+	query := r.db.WithContext(ctx).Model(&model.User{})
+	if notLoggedInSince != nil {
+		query = query.Where("last_login_at IS NULL OR last_login_at < ?", *notLoggedInSince)
+	}
+
 	// 获取总数
-	if err := r.db.WithContext(ctx).Model(&model.User{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 获取用户列表
-	if err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return users, total, nil
 }
 
+// exportColumns 导出用户时查询的字段，不包含密码等敏感字段
+var exportColumns = []string{"id", "username", "email", "role", "is_active", "created_at"}
+
+// FindInBatches 按ID顺序分批读取用户（只包含导出字段），每批调用一次fn
+func (r *Repository) FindInBatches(ctx context.Context, batchSize int, fn func(users []model.User) error) error {
+	var users []model.User
+	return r.db.WithContext(ctx).Select(exportColumns).FindInBatches(&users, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(users)
+	}).Error
+}
+
+// UpdateLastLogin 记录用户最后登录时间和IP（不更新updated_at）
+func (r *Repository) UpdateLastLogin(ctx context.Context, id uint, at time.Time, ip string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"last_login_at": at,
+		"last_login_ip": ip,
+	}).Error
+}
+
 // GetPasswordVersion 获取用户密码版本
 func (r *Repository) GetPasswordVersion(ctx context.Context, id uint) (uint, error) {
 	var user model.User
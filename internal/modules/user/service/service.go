@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"time"
 
+	auditModel "erp/internal/modules/audit/model"
 	"erp/internal/modules/user/model"
 	"erp/internal/modules/user/repository"
+	"erp/pkg/audit"
 	"erp/pkg/auth"
 	"erp/pkg/password"
 
@@ -54,14 +58,7 @@ func (s *Service) Register(ctx context.Context, req model.RegisterRequest) (*mod
 	}
 
 	// 返回用户信息（不包含密码）
-	return &model.Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toResponse(user), nil
 }
 
 // Login 用户登录
@@ -86,21 +83,22 @@ func (s *Service) Login(ctx context.Context, req model.LoginRequest) (*model.Log
 	}
 
 	// 生成JWT令牌（包含密码版本）
-	token, err := auth.GenerateToken(user.ID, user.Username, user.Role, user.PasswordVersion)
+	token, err := auth.GenerateToken(user.ID, user.Username, user.Role, user.PasswordVersion, user.MustChangePassword)
 	if err != nil {
 		return nil, errors.New("令牌生成失败")
 	}
 
-	// 返回用户信息和令牌
-	userResponse := model.Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
+	// 记录最后登录时间和IP，失败不影响登录
+	now := time.Now()
+	if err := s.repo.UpdateLastLogin(ctx, user.ID, now, audit.ClientIPFromContext(ctx)); err != nil {
+		log.Printf("Warning: 记录用户 %d 最后登录时间失败: %v", user.ID, err)
+	} else {
+		user.LastLoginAt = &now
 	}
 
+	// 返回用户信息和令牌
+	userResponse := *toResponse(user)
+
 	return &model.LoginResponse{
 		Token: token,
 		User:  userResponse,
@@ -117,14 +115,7 @@ func (s *Service) GetProfile(ctx context.Context, userID uint) (*model.Response,
 		return nil, errors.New("获取用户信息失败")
 	}
 
-	return &model.Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toResponse(user), nil
 }
 
 // UpdateProfile 更新用户资料
@@ -150,14 +141,7 @@ func (s *Service) UpdateProfile(ctx context.Context, userID uint, req model.Upda
 		return nil, errors.New("更新失败")
 	}
 
-	return &model.Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toResponse(user), nil
 }
 
 // ChangePassword 修改密码
@@ -184,6 +168,7 @@ func (s *Service) ChangePassword(ctx context.Context, userID uint, req model.Cha
 	// 更新密码和密码版本（使旧token失效）
 	user.Password = hashedPassword
 	user.PasswordVersion++ // 增加密码版本，使所有旧token失效
+	user.MustChangePassword = false
 	if err := s.repo.Update(ctx, user); err != nil {
 		return errors.New("密码更新失败")
 	}
@@ -191,11 +176,11 @@ func (s *Service) ChangePassword(ctx context.Context, userID uint, req model.Cha
 	return nil
 }
 
-// GetUsers 获取用户列表
-func (s *Service) GetUsers(ctx context.Context, page, limit int) (*model.UserListResponse, error) {
+// GetUsers 获取用户列表，notLoggedInSince 不为nil时只返回该时间之后未登录过的用户
+func (s *Service) GetUsers(ctx context.Context, page, limit int, notLoggedInSince *time.Time) (*model.UserListResponse, error) {
 	offset := (page - 1) * limit
 
-	users, total, err := s.repo.FindWithPagination(ctx, offset, limit)
+	users, total, err := s.repo.FindWithPagination(ctx, offset, limit, notLoggedInSince)
 	if err != nil {
 		return nil, errors.New("获取用户列表失败")
 	}
@@ -203,14 +188,7 @@ func (s *Service) GetUsers(ctx context.Context, page, limit int) (*model.UserLis
 	// 转换为响应格式
 	var userResponses []model.Response
 	for _, user := range users {
-		userResponses = append(userResponses, model.Response{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			Role:      user.Role,
-			IsActive:  user.IsActive,
-			CreatedAt: user.CreatedAt,
-		})
+		userResponses = append(userResponses, *toResponse(&user))
 	}
 
 	return &model.UserListResponse{
@@ -223,6 +201,20 @@ func (s *Service) GetUsers(ctx context.Context, page, limit int) (*model.UserLis
 	}, nil
 }
 
+// exportBatchSize 导出用户时每批读取的数量
+const exportBatchSize = 500
+
+// ExportUsers 分批读取全部用户并转换为响应格式（不包含密码），每批调用一次fn
+func (s *Service) ExportUsers(ctx context.Context, fn func(users []model.Response) error) error {
+	return s.repo.FindInBatches(ctx, exportBatchSize, func(users []model.User) error {
+		batch := make([]model.Response, 0, len(users))
+		for i := range users {
+			batch = append(batch, *toResponse(&users[i]))
+		}
+		return fn(batch)
+	})
+}
+
 // AdminCreateUser 管理员创建用户
 func (s *Service) AdminCreateUser(ctx context.Context, req model.AdminCreateUserRequest) (*model.Response, error) {
 	// 检查用户名是否已存在
@@ -248,21 +240,21 @@ func (s *Service) AdminCreateUser(ctx context.Context, req model.AdminCreateUser
 		Password: hashedPassword,
 		Role:     req.Role,
 		IsActive: true,
+		// 管理员设置的初始密码，要求用户首次登录后修改
+		MustChangePassword: true,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, errors.New("用户创建失败")
 	}
 
+	audit.Record(ctx, auditModel.ActionCreate, "user", user.ID, map[string]interface{}{
+		"username": user.Username,
+		"role":     user.Role,
+	})
+
 	// 返回用户信息（不包含密码）
-	return &model.Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toResponse(user), nil
 }
 
 // AdminUpdateUser 管理员更新用户
@@ -301,16 +293,15 @@ func (s *Service) AdminUpdateUser(ctx context.Context, userID uint, req model.Ad
 		if err := s.repo.Update(ctx, user); err != nil {
 			return nil, errors.New("更新失败")
 		}
+
+		audit.Record(ctx, auditModel.ActionUpdate, "user", user.ID, map[string]interface{}{
+			"email":     user.Email,
+			"role":      user.Role,
+			"is_active": user.IsActive,
+		})
 	}
 
-	return &model.Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toResponse(user), nil
 }
 
 // AdminResetUserPassword 管理员重置用户密码
@@ -332,10 +323,12 @@ func (s *Service) AdminResetUserPassword(ctx context.Context, userID uint, req m
 	// 更新密码和密码版本（使旧token失效）
 	user.Password = hashedPassword
 	user.PasswordVersion++ // 增加密码版本，使所有旧token失效
+	user.MustChangePassword = true
 	if err := s.repo.Update(ctx, user); err != nil {
 		return errors.New("密码重置失败")
 	}
 
+	audit.Record(ctx, auditModel.ActionUpdate, "user", user.ID, map[string]interface{}{"password_reset": true})
 	return nil
 }
 
@@ -353,5 +346,55 @@ func (s *Service) AdminDeleteUser(ctx context.Context, userID uint) error {
 		return errors.New("删除用户失败")
 	}
 
+	audit.Record(ctx, auditModel.ActionDelete, "user", userID, nil)
 	return nil
 }
+
+// EnsureDefaultAdmin 用户表为空时创建默认管理员（幂等，已存在任何用户时跳过）
+func (s *Service) EnsureDefaultAdmin(ctx context.Context, username, email, plainPassword string) (bool, error) {
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return false, errors.New("统计用户数量失败")
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if plainPassword == "" {
+		return false, errors.New("未配置默认管理员密码")
+	}
+
+	hashedPassword, err := password.Hash(plainPassword)
+	if err != nil {
+		return false, errors.New("密码加密失败")
+	}
+
+	user := &model.User{
+		Username:           username,
+		Email:              email,
+		Password:           hashedPassword,
+		Role:               "admin",
+		IsActive:           true,
+		MustChangePassword: true,
+	}
+
+	if err := s.repo.Create(ctx, user); err != nil {
+		return false, errors.New("默认管理员创建失败")
+	}
+
+	return true, nil
+}
+
+// toResponse 将用户模型转换为响应结构（不包含密码）
+func toResponse(user *model.User) *model.Response {
+	return &model.Response{
+		ID:                 user.ID,
+		Username:           user.Username,
+		Email:              user.Email,
+		Role:               user.Role,
+		IsActive:           user.IsActive,
+		MustChangePassword: user.MustChangePassword,
+		LastLoginAt:        user.LastLoginAt,
+		CreatedAt:          user.CreatedAt,
+	}
+}
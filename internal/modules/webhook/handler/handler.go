@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"erp/internal/modules/webhook/model"
+	"erp/internal/modules/webhook/service"
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	svc service.WebhookService
+}
+
+func NewWebhookHandler(svc service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+// CreateWebhookRequest 创建Webhook请求
+type CreateWebhookRequest struct {
+	URL      string   `json:"url" binding:"required,max=500" example:"https://wms.example.com/hooks/erp"`                      // 回调地址
+	Events   []string `json:"events" binding:"required,min=1" example:"product.created,product.updated,product.stock_changed"` // 订阅的事件类型
+	Secret   string   `json:"secret" binding:"required,min=16,max=255" example:"a-long-random-secret-value"`                   // 签名密钥
+	IsActive *bool    `json:"is_active,omitempty" example:"true"`                                                              // 是否启用，默认启用
+}
+
+// UpdateWebhookRequest 更新Webhook请求（字段都是可选的）
+type UpdateWebhookRequest struct {
+	URL      *string   `json:"url,omitempty" binding:"omitempty,max=500" example:"https://wms.example.com/hooks/erp"`
+	Events   *[]string `json:"events,omitempty" example:"product.created"`
+	Secret   *string   `json:"secret,omitempty" binding:"omitempty,min=16,max=255" example:"a-long-random-secret-value"`
+	IsActive *bool     `json:"is_active,omitempty" example:"true"`
+}
+
+// @Summary 创建Webhook订阅
+// @Description 创建Webhook订阅（需要管理员权限），事件发生时向回调地址POST签名后的JSON（X-Webhook-Signature: sha256=HMAC-SHA256(body, secret)）
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateWebhookRequest true "Webhook信息"
+// @Success 200 {object} response.Response{data=internal_modules_webhook_model.Webhook} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	webhook := &model.Webhook{
+		URL:      req.URL,
+		Events:   req.Events,
+		Secret:   req.Secret,
+		IsActive: true,
+	}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+
+	if err := h.svc.CreateWebhook(c.Request.Context(), webhook); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("创建Webhook成功", webhook))
+}
+
+// @Summary 获取Webhook订阅列表
+// @Description 获取所有Webhook订阅（需要管理员权限）
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]internal_modules_webhook_model.Webhook} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	webhooks, err := h.svc.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取Webhook列表成功", webhooks))
+}
+
+// @Summary 获取Webhook订阅详情
+// @Description 获取指定ID的Webhook订阅（需要管理员权限）
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} response.Response{data=internal_modules_webhook_model.Webhook} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "Webhook不存在"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的Webhook ID"))
+		return
+	}
+
+	webhook, err := h.svc.GetWebhook(c.Request.Context(), uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取Webhook成功", webhook))
+}
+
+// @Summary 更新Webhook订阅
+// @Description 更新指定ID的Webhook订阅（需要管理员权限），只更新提供的字段
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param request body UpdateWebhookRequest true "Webhook信息"
+// @Success 200 {object} response.Response{data=internal_modules_webhook_model.Webhook} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "Webhook不存在"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的Webhook ID"))
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ValidationError(c, err))
+		return
+	}
+
+	webhook, err := h.svc.GetWebhook(c.Request.Context(), uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		webhook.Events = *req.Events
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+
+	if err := h.svc.UpdateWebhook(c.Request.Context(), webhook); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("更新Webhook成功", webhook))
+}
+
+// @Summary 删除Webhook订阅
+// @Description 删除指定ID的Webhook订阅及其投递记录（需要管理员权限）
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "Webhook不存在"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的Webhook ID"))
+		return
+	}
+
+	if err := h.svc.DeleteWebhook(c.Request.Context(), uint(id)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("删除Webhook成功", "Webhook已删除"))
+}
+
+// @Summary 获取Webhook投递记录
+// @Description 分页获取指定Webhook的投递记录（需要管理员权限）
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param page query int false "页码，默认1" default(1)
+// @Param page_size query int false "每页数量，默认20" default(20)
+// @Success 200 {object} response.Response{data=object{items=[]internal_modules_webhook_model.WebhookDelivery,total=int64,page=int,page_size=int}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "Webhook不存在"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("无效的Webhook ID"))
+		return
+	}
+
+	page, pageSize := response.PageParams(c, "webhook_delivery", "page_size")
+
+	deliveries, total, err := h.svc.ListDeliveries(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success("获取投递记录成功", gin.H{
+		"items":     deliveries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}))
+}
+
+// respondError 根据错误信息返回对应的状态码
+func respondError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "Webhook不存在":
+		c.JSON(http.StatusNotFound, response.Error(err.Error()))
+	case err.Error() == "无效的回调地址", err.Error() == "至少需要订阅一个事件", strings.HasPrefix(err.Error(), "不支持的事件类型"):
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, response.Error(err.Error()))
+	}
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// 事件类型
+const (
+	EventProductCreated      = "product.created"
+	EventProductUpdated      = "product.updated"
+	EventProductDeleted      = "product.deleted"
+	EventProductStockChanged = "product.stock_changed"
+)
+
+// SupportedEvents 支持订阅的事件类型
+var SupportedEvents = []string{
+	EventProductCreated,
+	EventProductUpdated,
+	EventProductDeleted,
+	EventProductStockChanged,
+}
+
+// IsSupportedEvent 判断是否为支持的事件类型
+func IsSupportedEvent(event string) bool {
+	for _, supported := range SupportedEvents {
+		if supported == event {
+			return true
+		}
+	}
+	return false
+}
+
+// StringList 字符串列表，以JSON数组存储
+type StringList []string
+
+// Value 实现 driver.Valuer 接口
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (l *StringList) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("无法将值转换为 StringList")
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// Contains 判断列表中是否包含指定值
+func (l StringList) Contains(value string) bool {
+	for _, item := range l {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook Webhook订阅
+// @Description Webhook订阅信息
+type Webhook struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	URL       string     `json:"url" gorm:"type:varchar(500);not null" example:"https://wms.example.com/hooks/erp"` // 回调地址
+	Events    StringList `json:"events" gorm:"type:json" swaggertype:"array,string" example:"product.created"`      // 订阅的事件类型
+	Secret    string     `json:"-" gorm:"type:varchar(255);not null"`                                               // 签名密钥
+	IsActive  bool       `json:"is_active" gorm:"default:true" example:"true"`                                      // 是否启用
+}
+
+// WebhookDelivery Webhook投递记录（每次尝试一条）
+// @Description Webhook投递记录
+type WebhookDelivery struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WebhookID  uint      `json:"webhook_id" gorm:"index" example:"1"`                              // Webhook ID
+	Event      string    `json:"event" gorm:"type:varchar(50);not null" example:"product.created"` // 事件类型
+	Attempt    int       `json:"attempt" example:"1"`                                              // 第几次尝试
+	StatusCode int       `json:"status_code" example:"200"`                                        // 响应状态码，请求失败时为0
+	Success    bool      `json:"success" example:"true"`                                           // 是否投递成功
+	Error      string    `json:"error,omitempty" gorm:"type:text"`                                 // 错误信息
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"erp/internal/modules/webhook/handler"
+	"erp/internal/modules/webhook/repository"
+	"erp/internal/modules/webhook/service"
+
+	"gorm.io/gorm"
+)
+
+type Module struct {
+	db      *gorm.DB
+	repo    repository.WebhookRepository
+	handler *handler.WebhookHandler
+}
+
+func NewModule(db *gorm.DB) *Module {
+	// 创建依赖
+	repo := repository.NewWebhookRepository(db)
+	svc := service.NewWebhookService(repo)
+	h := handler.NewWebhookHandler(svc)
+
+	return &Module{
+		db:      db,
+		repo:    repo,
+		handler: h,
+	}
+}
+
+// GetHandler 获取Webhook处理器
+func (m *Module) GetHandler() *handler.WebhookHandler {
+	return m.handler
+}
+
+// GetRepository 获取Webhook仓库
+func (m *Module) GetRepository() repository.WebhookRepository {
+	return m.repo
+}
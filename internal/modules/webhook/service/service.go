@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	auditModel "erp/internal/modules/audit/model"
+	"erp/internal/modules/webhook/model"
+	"erp/internal/modules/webhook/repository"
+	"erp/pkg/audit"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id uint) error
+	GetWebhook(ctx context.Context, id uint) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	ListDeliveries(ctx context.Context, webhookID uint, page, pageSize int) ([]model.WebhookDelivery, int64, error)
+}
+
+type webhookService struct {
+	repo repository.WebhookRepository
+}
+
+func NewWebhookService(repo repository.WebhookRepository) WebhookService {
+	return &webhookService{repo: repo}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionCreate, "webhook", webhook.ID, map[string]interface{}{
+		"url":    webhook.URL,
+		"events": webhook.Events,
+	})
+	return nil
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	if _, err := s.repo.FindByID(ctx, webhook.ID); err != nil {
+		return errors.New("Webhook不存在")
+	}
+
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionUpdate, "webhook", webhook.ID, map[string]interface{}{
+		"url":       webhook.URL,
+		"events":    webhook.Events,
+		"is_active": webhook.IsActive,
+	})
+	return nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uint) error {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return errors.New("Webhook不存在")
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, auditModel.ActionDelete, "webhook", id, nil)
+	return nil
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, id uint) (*model.Webhook, error) {
+	webhook, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("Webhook不存在")
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookID uint, page, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	if _, err := s.repo.FindByID(ctx, webhookID); err != nil {
+		return nil, 0, errors.New("Webhook不存在")
+	}
+	return s.repo.ListDeliveries(ctx, webhookID, page, pageSize)
+}
+
+// validateWebhook 校验回调地址和订阅的事件类型
+func validateWebhook(webhook *model.Webhook) error {
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("无效的回调地址")
+	}
+
+	if len(webhook.Events) == 0 {
+		return errors.New("至少需要订阅一个事件")
+	}
+	for _, event := range webhook.Events {
+		if !model.IsSupportedEvent(event) {
+			return errors.New("不支持的事件类型: " + event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"erp/internal/modules/audit/model"
+	"erp/internal/modules/audit/repository"
+	"erp/pkg/auth"
+)
+
+// queueSize 审计日志异步写入队列长度
+const queueSize = 1000
+
+// writeTimeout 单条审计日志写入超时时间
+const writeTimeout = 5 * time.Second
+
+type contextKey string
+
+const clientIPContextKey contextKey = "client_ip"
+
+var queue chan *model.AuditLog
+
+// Init 初始化审计日志记录器，启动后台写入协程
+func Init(repo repository.AuditLogRepository) {
+	queue = make(chan *model.AuditLog, queueSize)
+	go func() {
+		for entry := range queue {
+			ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+			if err := repo.Create(ctx, entry); err != nil {
+				log.Printf("Warning: 审计日志写入失败: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// WithClientIP 将客户端IP写入context，供审计日志记录
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPContextKey, ip)
+}
+
+// ClientIPFromContext 从context中获取客户端IP，未设置时返回空字符串
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPContextKey).(string)
+	return ip
+}
+
+// Record 异步记录一条审计日志，操作人和客户端IP从context中获取
+// detail 为任意可JSON序列化的操作详情，可为nil
+func Record(ctx context.Context, action, entityType string, entityID uint, detail interface{}) {
+	if queue == nil {
+		return
+	}
+
+	entry := &model.AuditLog{
+		UserID:     auth.UserIDFromContext(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		CreatedAt:  time.Now(),
+	}
+	entry.IP = ClientIPFromContext(ctx)
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			log.Printf("Warning: 审计日志详情序列化失败: %v", err)
+		} else {
+			entry.Detail = model.JSON(data)
+		}
+	}
+
+	// 队列已满时丢弃，避免阻塞主请求
+	select {
+	case queue <- entry:
+	default:
+		log.Printf("Warning: 审计日志队列已满，丢弃记录 action=%s entity=%s:%d", action, entityType, entityID)
+	}
+}
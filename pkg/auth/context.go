@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+type contextKey string
+
+const userIDContextKey contextKey = "user_id"
+
+// WithUserID 将当前用户ID写入context，供service层读取
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext 从context中获取当前用户ID，未认证时返回0
+func UserIDFromContext(ctx context.Context) uint {
+	userID, _ := ctx.Value(userIDContextKey).(uint)
+	return userID
+}
@@ -11,20 +11,22 @@ import (
 
 // Claims JWT声明
 type Claims struct {
-	UserID          uint   `json:"user_id"`
-	Username        string `json:"username"`
-	Role            string `json:"role"`
-	PasswordVersion uint   `json:"password_version"` // 密码版本，用于验证token是否有效
+	UserID             uint   `json:"user_id"`
+	Username           string `json:"username"`
+	Role               string `json:"role"`
+	PasswordVersion    uint   `json:"password_version"`               // 密码版本，用于验证token是否有效
+	MustChangePassword bool   `json:"must_change_password,omitempty"` // 是否需要强制修改密码
 	jwt.RegisteredClaims
 }
 
 // GenerateToken 生成JWT令牌
-func GenerateToken(userID uint, username, role string, passwordVersion uint) (string, error) {
+func GenerateToken(userID uint, username, role string, passwordVersion uint, mustChangePassword bool) (string, error) {
 	claims := Claims{
-		UserID:          userID,
-		Username:        username,
-		Role:            role,
-		PasswordVersion: passwordVersion,
+		UserID:             userID,
+		Username:           username,
+		Role:               role,
+		PasswordVersion:    passwordVersion,
+		MustChangePassword: mustChangePassword,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(config.AppConfig.JWTExpireHours) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -33,14 +35,17 @@ func GenerateToken(userID uint, username, role string, passwordVersion uint) (st
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = config.AppConfig.JWTKeyID
 	return token.SignedString([]byte(config.AppConfig.JWTSecret))
 }
 
 // ParseToken 解析JWT令牌
 func ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
+	// 固定签名算法为HS256，拒绝alg为none或其他算法的令牌
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithLeeway(time.Duration(config.AppConfig.JWTLeewaySeconds)*time.Second),
+	)
 
 	if err != nil {
 		return nil, err
@@ -53,6 +58,31 @@ func ParseToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("无效的令牌")
 }
 
+// verificationKey 根据令牌头中的kid选择验证密钥（当前密钥或历史密钥）
+// 未携带kid的令牌（轮换功能上线前签发）使用当前密钥验证
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodHS256 {
+		return nil, errors.New("不支持的签名算法")
+	}
+
+	kid, ok := token.Header["kid"]
+	if !ok {
+		return []byte(config.AppConfig.JWTSecret), nil
+	}
+
+	keyID, ok := kid.(string)
+	if !ok {
+		return nil, errors.New("无效的密钥ID")
+	}
+	if keyID == config.AppConfig.JWTKeyID {
+		return []byte(config.AppConfig.JWTSecret), nil
+	}
+	if secret, ok := config.AppConfig.JWTPreviousSecrets[keyID]; ok {
+		return []byte(secret), nil
+	}
+	return nil, errors.New("未知的密钥ID")
+}
+
 // ValidateTokenPasswordVersion 验证token的密码版本是否有效
 // 这个函数需要从数据库获取当前用户的密码版本进行比较
 func ValidateTokenPasswordVersion(claims *Claims, currentPasswordVersion uint) bool {
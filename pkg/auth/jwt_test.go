@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"erp/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret         = "current-secret-at-least-32-characters"
+	testPreviousSecret = "previous-secret-at-least-32-characters"
+)
+
+func setupConfig(t *testing.T) {
+	t.Helper()
+	original := config.AppConfig
+	config.AppConfig = &config.Config{
+		JWTSecret:          testSecret,
+		JWTExpireHours:     1,
+		JWTKeyID:           "k2",
+		JWTPreviousSecrets: map[string]string{"k1": testPreviousSecret},
+		JWTLeewaySeconds:   30,
+	}
+	t.Cleanup(func() { config.AppConfig = original })
+}
+
+func testClaims(expiresAt time.Time) Claims {
+	return Claims{
+		UserID:   1,
+		Username: "admin",
+		Role:     "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}
+}
+
+// signHS 使用指定算法、kid和密钥签发令牌；kid为空时不写入令牌头
+func signHS(t *testing.T, method jwt.SigningMethod, kid, secret string, expiresAt time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, testClaims(expiresAt))
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return signed
+}
+
+func TestParseTokenSigningMethod(t *testing.T) {
+	setupConfig(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	noneToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims(expiresAt)).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("签发alg:none令牌失败: %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+	rsToken := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims(expiresAt))
+	rsToken.Header["kid"] = "k2"
+	rs256Token, err := rsToken.SignedString(rsaKey)
+	if err != nil {
+		t.Fatalf("签发RS256令牌失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"HS256", signHS(t, jwt.SigningMethodHS256, "k2", testSecret, expiresAt), false},
+		{"alg none", noneToken, true},
+		{"HS384", signHS(t, jwt.SigningMethodHS384, "k2", testSecret, expiresAt), true},
+		{"RS256", rs256Token, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTokenLeeway(t *testing.T) {
+	setupConfig(t)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{"未过期", time.Now().Add(time.Minute), false},
+		{"过期但在允许偏差内", time.Now().Add(-10 * time.Second), false},
+		{"过期且超出允许偏差", time.Now().Add(-time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signHS(t, jwt.SigningMethodHS256, "k2", testSecret, tt.expiresAt)
+			_, err := ParseToken(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTokenKeyID(t *testing.T) {
+	setupConfig(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		kid     string
+		secret  string
+		wantErr bool
+	}{
+		{"当前kid", "k2", testSecret, false},
+		{"历史kid", "k1", testPreviousSecret, false},
+		{"历史kid使用当前密钥签名", "k1", testSecret, true},
+		{"未知kid", "k0", testSecret, true},
+		{"未携带kid使用当前密钥", "", testSecret, false},
+		{"未携带kid使用历史密钥", "", testPreviousSecret, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signHS(t, jwt.SigningMethodHS256, tt.kid, tt.secret, expiresAt)
+			claims, err := ParseToken(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && claims.UserID != 1 {
+				t.Fatalf("ParseToken() UserID = %d, want 1", claims.UserID)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenRoundTrip(t *testing.T) {
+	setupConfig(t)
+
+	token, err := GenerateToken(7, "alice", "user", 3, false)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.UserID != 7 || claims.Username != "alice" || claims.PasswordVersion != 3 {
+		t.Fatalf("ParseToken() claims = %+v", claims)
+	}
+}
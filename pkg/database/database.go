@@ -3,12 +3,9 @@ package database
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"erp/config"
-	productModel "erp/internal/modules/product/model"
-	sourceModel "erp/internal/modules/source/model"
-	tagsModel "erp/internal/modules/tags/model"
-	"erp/internal/modules/user/model"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -17,6 +14,12 @@ import (
 
 var DB *gorm.DB
 
+// ReadDB 只读副本连接，未配置或连接失败时为nil
+var ReadDB *gorm.DB
+
+// maxRetryInterval 数据库连接重试的最大等待间隔
+const maxRetryInterval = 30 * time.Second
+
 // InitDatabase 初始化数据库连接
 func InitDatabase() {
 	cfg := config.AppConfig
@@ -25,34 +28,67 @@ func InitDatabase() {
 		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
 
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-
+	DB, err = connectWithRetry(dsn, cfg.DBConnectMaxRetries, time.Duration(cfg.DBConnectRetryInterval)*time.Second)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
 	log.Println("Database connected successfully")
 
-	// 自动迁移数据库表
-	err = DB.AutoMigrate(
-		&model.User{},
-		&sourceModel.Source{},
-		&productModel.Product{},
-		&productModel.Color{},
-		&productModel.ProductColor{},
-		&tagsModel.Tag{},
-		&tagsModel.ProductTag{},
-	)
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// 可选的只读副本，连接失败时回退到主库
+	if cfg.DBReadDSN != "" {
+		ReadDB, err = connectWithRetry(cfg.DBReadDSN, cfg.DBConnectMaxRetries, time.Duration(cfg.DBConnectRetryInterval)*time.Second)
+		if err != nil {
+			log.Printf("Warning: 只读副本连接失败，只读查询将使用主库: %v", err)
+			ReadDB = nil
+		} else {
+			log.Println("Read replica connected successfully")
+		}
+	}
+}
+
+// connectWithRetry 连接数据库，失败时按指数退避重试，直到达到最大重试次数
+func connectWithRetry(dsn string, maxRetries int, interval time.Duration) (*gorm.DB, error) {
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
 	}
 
-	log.Println("Database migration completed")
+	var db *gorm.DB
+	var err error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Info),
+		})
+		if err == nil {
+			return db, nil
+		}
+
+		log.Printf("Database connection attempt %d/%d failed: %v", attempt, maxRetries, err)
+		if attempt < maxRetries {
+			wait := interval * time.Duration(1<<(attempt-1))
+			if wait > maxRetryInterval {
+				wait = maxRetryInterval
+			}
+			log.Printf("Retrying database connection in %s", wait)
+			time.Sleep(wait)
+		}
+	}
+
+	return nil, err
 }
 
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// GetReadDB 获取只读查询使用的数据库实例，未配置只读副本时返回主库
+func GetReadDB() *gorm.DB {
+	if ReadDB != nil {
+		return ReadDB
+	}
+	return DB
+}
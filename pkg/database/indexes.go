@@ -0,0 +1,113 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// productFilterIndexes 商品列表常用筛选字段的索引（只对未删除的记录生效）
+var productFilterIndexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_products_name ON products (name) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_product_code ON products (product_code) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_price ON products (price) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_is_enabled ON products (is_enabled) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_shipping_time ON products (shipping_time) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_source_enabled ON products (source_id, is_enabled) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_enabled_created ON products (is_enabled, created_at DESC) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_product_colors_color ON product_colors (color_id, product_id)",
+	"CREATE INDEX IF NOT EXISTS idx_product_tags_tag ON product_tags (tag_id, product_id)",
+}
+
+// productTrigramIndexes 商品文本模糊搜索（ILIKE '%关键字%'）使用的pg_trgm GIN索引
+// B-tree索引无法支持前后模糊匹配，上面的 name、product_code、shipping_time 索引仅用于排序和精确查询
+var productTrigramIndexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_product_code_trgm ON products USING gin (product_code gin_trgm_ops) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_shipping_time_trgm ON products USING gin (shipping_time gin_trgm_ops) WHERE deleted_at IS NULL",
+}
+
+// conditionalUniqueIndexes 条件唯一索引，只对未删除的记录生效，软删除后可重新创建同名记录
+var conditionalUniqueIndexes = []string{
+	"CREATE UNIQUE INDEX IF NOT EXISTS uniq_users_username_active ON users (username) WHERE deleted_at IS NULL",
+	"CREATE UNIQUE INDEX IF NOT EXISTS uniq_users_email_active ON users (email) WHERE deleted_at IS NULL",
+}
+
+// tagNameActiveUniqueIndex 标签名称改为只对未删除标签唯一，已删除或被合并的标签不再占用名称
+var tagNameActiveUniqueIndex = []string{
+	"DROP INDEX IF EXISTS idx_tags_name",
+	"CREATE UNIQUE INDEX IF NOT EXISTS uniq_tags_name_active ON tags (name) WHERE deleted_at IS NULL",
+}
+
+// createIndexes 创建AutoMigrate无法表达的索引（条件索引、复合索引）
+func createIndexes(db *gorm.DB) error {
+	if err := checkActiveUserDuplicates(db); err != nil {
+		return err
+	}
+	stmts := append(append([]string{}, productFilterIndexes...), conditionalUniqueIndexes...)
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// replaceTagNameUniqueIndex 将标签名称的全表唯一索引替换为条件唯一索引
+func replaceTagNameUniqueIndex(db *gorm.DB) error {
+	for _, stmt := range tagNameActiveUniqueIndex {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// checkActiveUserDuplicates 创建条件唯一索引前检查未删除用户中是否存在重复的用户名或邮箱
+// 存在重复时返回列出冲突记录的错误，需要先人工处理（修改或软删除多余账号）后再重新启动
+func checkActiveUserDuplicates(db *gorm.DB) error {
+	var conflicts []string
+	for _, column := range []string{"username", "email"} {
+		var rows []struct {
+			Value string `gorm:"column:value"`
+			IDs   string `gorm:"column:ids"`
+		}
+		err := db.Raw(fmt.Sprintf(
+			"SELECT %[1]s AS value, string_agg(id::text, ',' ORDER BY id) AS ids FROM users WHERE deleted_at IS NULL GROUP BY %[1]s HAVING COUNT(*) > 1 ORDER BY %[1]s",
+			column,
+		)).Scan(&rows).Error
+		if err != nil {
+			return fmt.Errorf("检查重复的 %s 失败: %w", column, err)
+		}
+		for _, row := range rows {
+			conflicts = append(conflicts, fmt.Sprintf("%s=%q (用户ID: %s)", column, row.Value, row.IDs))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("未删除用户中存在重复记录，无法创建唯一索引，请先处理: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// createTrigramIndexes 创建模糊搜索索引
+// pg_trgm 扩展需要由有权限的数据库用户预先执行 CREATE EXTENSION pg_trgm 启用，应用账号通常没有该权限；
+// 扩展未启用时跳过索引创建并输出警告，模糊搜索仍可用，只是无法走索引
+func createTrigramIndexes(db *gorm.DB) error {
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'pg_trgm'").Scan(&count).Error; err != nil {
+		return fmt.Errorf("检查 pg_trgm 扩展失败: %w", err)
+	}
+	if count == 0 {
+		log.Printf("Warning: pg_trgm 扩展未启用，跳过模糊搜索索引；请由数据库管理员执行 CREATE EXTENSION pg_trgm 后手动创建 productTrigramIndexes 中的索引")
+		return nil
+	}
+	for _, stmt := range productTrigramIndexes {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(200);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// migration 一个有序、带版本号的迁移
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// migrations 迁移列表，按版本号递增排列；已发布的迁移不要修改，新的表结构变更请追加新版本
+// 迁移中使用冻结的快照结构体或SQL，不要引用各模块的模型，否则模型后续的修改会改变已发布迁移的行为
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up:      migrateInitialSchema,
+	},
+	{
+		Version: 2,
+		Name:    "filter_and_conditional_unique_indexes",
+		Up:      createIndexes,
+	},
+	{
+		Version: 3,
+		Name:    "product_query_histories",
+		Up: func(tx *gorm.DB) error {
+			type ProductQueryHistory struct {
+				ID        uint      `gorm:"primaryKey"`
+				UserID    uint      `gorm:"index:idx_query_history_user_created,priority:1;not null"`
+				ProductID uint      `gorm:"index;not null"`
+				SKU       string    `gorm:"type:varchar(50)"`
+				CreatedAt time.Time `gorm:"index:idx_query_history_user_created,priority:2,sort:desc"`
+			}
+			return tx.AutoMigrate(&ProductQueryHistory{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "users_last_login",
+		Up: func(tx *gorm.DB) error {
+			type User struct {
+				LastLoginAt *time.Time
+				LastLoginIP string `gorm:"type:varchar(64)"`
+			}
+			for _, field := range []string{"LastLoginAt", "LastLoginIP"} {
+				if tx.Migrator().HasColumn(&User{}, field) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&User{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 5,
+		Name:    "product_trigram_search_indexes",
+		Up:      createTrigramIndexes,
+	},
+	{
+		Version: 6,
+		Name:    "tags_name_active_unique_index",
+		Up:      replaceTagNameUniqueIndex,
+	},
+}
+
+// RunMigrations 按版本号依次执行尚未执行的迁移，每个迁移在独立事务中执行并记录到 schema_migrations
+func RunMigrations() error {
+	if err := DB.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("创建 schema_migrations 失败: %w", err)
+	}
+
+	var applied []SchemaMigration
+	if err := DB.Find(&applied).Error; err != nil {
+		return err
+	}
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return fmt.Errorf("迁移版本号必须递增: %d", m.Version)
+		}
+		if done[m.Version] {
+			continue
+		}
+
+		log.Printf("Applying migration %d_%s", m.Version, m.Name)
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrateInitialSchema 迁移1：创建初始表结构
+// 表结构以快照结构体的形式冻结在这里，不引用各模块的模型；之后模型的字段变更必须追加新的迁移版本，
+// 否则新库和已升级的库表结构会不一致。结构体名称决定了多对多关联表的列名和外键约束名，不要修改
+func migrateInitialSchema(tx *gorm.DB) error {
+	type User struct {
+		ID                 uint   `gorm:"primaryKey"`
+		Username           string `gorm:"not null;index"`
+		Email              string `gorm:"not null;index"`
+		Password           string `gorm:"not null"`
+		PasswordVersion    uint   `gorm:"default:1"`
+		Role               string `gorm:"default:'user'"`
+		IsActive           bool   `gorm:"default:true"`
+		MustChangePassword bool   `gorm:"default:false"`
+		CreatedAt          time.Time
+		UpdatedAt          time.Time
+		DeletedAt          gorm.DeletedAt `gorm:"index"`
+	}
+
+	type Source struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt *time.Time `gorm:"index"`
+		Name      string     `gorm:"type:varchar(100);not null"`
+		Code      string     `gorm:"type:varchar(50);uniqueIndex;not null"`
+		Status    int        `gorm:"default:1"`
+		Remark    string     `gorm:"type:text"`
+		Version   int        `gorm:"default:1;not null"`
+	}
+
+	type Color struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+		Name      string         `gorm:"type:varchar(50);uniqueIndex;not null"`
+		Code      string         `gorm:"type:varchar(20);uniqueIndex"`
+		HexColor  string         `gorm:"type:varchar(7)"`
+	}
+
+	type Tag struct {
+		ID          uint `gorm:"primaryKey"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		DeletedAt   *time.Time `gorm:"index"`
+		Name        string     `gorm:"type:varchar(50);uniqueIndex;not null"`
+		Description string     `gorm:"type:varchar(200)"`
+		Color       string     `gorm:"type:varchar(7)"`
+		IsEnabled   bool       `gorm:"default:true"`
+	}
+
+	type Product struct {
+		ID            uint `gorm:"primaryKey"`
+		CreatedAt     time.Time
+		UpdatedAt     time.Time
+		DeletedAt     gorm.DeletedAt `gorm:"index"`
+		Name          string         `gorm:"type:varchar(100);not null"`
+		SKU           string         `gorm:"type:varchar(50);not null"`
+		ProductCode   string         `gorm:"type:varchar(100)"`
+		SourceID      *uint          `gorm:"index"`
+		Source        *Source        `gorm:"foreignKey:SourceID"`
+		Price         float64        `gorm:"type:decimal(10,2);not null"`
+		IsDiscounted  bool           `gorm:"default:false"`
+		DiscountPrice float64        `gorm:"type:decimal(10,2)"`
+		CostPrice     float64        `gorm:"type:decimal(10,2);not null"`
+		Images        string         `gorm:"type:json"`
+		Colors        []Color        `gorm:"many2many:product_colors;"`
+		Tags          []Tag          `gorm:"many2many:product_tags;"`
+		ShippingTime  string         `gorm:"type:varchar(50)"`
+		IsEnabled     bool           `gorm:"default:true"`
+		CreatedBy     uint           `gorm:"index"`
+		UpdatedBy     uint
+		Stock         int `gorm:"default:0;index"`
+		ReorderLevel  int `gorm:"default:0"`
+		Version       int `gorm:"default:1;not null"`
+	}
+
+	type ProductColor struct {
+		ProductID uint `gorm:"primaryKey"`
+		ColorID   uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+	}
+
+	type ProductTag struct {
+		ProductID uint `gorm:"primaryKey"`
+		TagID     uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+	}
+
+	type AuditLog struct {
+		ID         uint      `gorm:"primaryKey"`
+		UserID     uint      `gorm:"index"`
+		Action     string    `gorm:"type:varchar(50);not null;index"`
+		EntityType string    `gorm:"type:varchar(50);not null;index"`
+		EntityID   uint      `gorm:"index"`
+		Detail     string    `gorm:"type:json"`
+		IP         string    `gorm:"type:varchar(64)"`
+		CreatedAt  time.Time `gorm:"index"`
+	}
+
+	type Webhook struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		URL       string `gorm:"type:varchar(500);not null"`
+		Events    string `gorm:"type:json"`
+		Secret    string `gorm:"type:varchar(255);not null"`
+		IsActive  bool   `gorm:"default:true"`
+	}
+
+	type WebhookDelivery struct {
+		ID         uint   `gorm:"primaryKey"`
+		WebhookID  uint   `gorm:"index"`
+		Event      string `gorm:"type:varchar(50);not null"`
+		Attempt    int
+		StatusCode int
+		Success    bool
+		Error      string    `gorm:"type:text"`
+		CreatedAt  time.Time `gorm:"index"`
+	}
+
+	return tx.AutoMigrate(
+		&User{},
+		&Source{},
+		&Product{},
+		&Color{},
+		&ProductColor{},
+		&Tag{},
+		&ProductTag{},
+		&AuditLog{},
+		&Webhook{},
+		&WebhookDelivery{},
+	)
+}
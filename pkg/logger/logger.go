@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Init 根据配置初始化全局结构化日志（log/slog）
+// level: debug/info/warn/error；format: json 输出JSON，其他值输出文本格式
+// 设置后标准库 log 包的输出也会经由该 handler 以 INFO 级别输出
+func Init(level, format string) {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
+
+// ParseLevel 解析日志级别，无法识别时返回 INFO
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"erp/config"
+	"erp/internal/modules/user/repository"
+	"erp/pkg/auth"
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader API Key请求头
+const APIKeyHeader = "X-API-Key"
+
+// ServiceRole 服务调用方的角色标识
+const ServiceRole = "service"
+
+// APIKeyMiddleware API Key认证中间件（用于服务间调用）
+func APIKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticateAPIKey(c) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthOrAPIKeyMiddleware 认证中间件：携带X-API-Key时使用API Key认证，否则使用JWT认证（包含密码版本验证）
+func AuthOrAPIKeyMiddleware(userRepo *repository.Repository) gin.HandlerFunc {
+	jwtAuth := AuthMiddlewareWithPasswordValidation(userRepo)
+	return func(c *gin.Context) {
+		if c.GetHeader(APIKeyHeader) == "" {
+			jwtAuth(c)
+			return
+		}
+
+		if !authenticateAPIKey(c) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticateAPIKey 校验API Key，成功时在上下文中写入服务身份，失败时返回401并中止请求
+func authenticateAPIKey(c *gin.Context) bool {
+	apiKey := c.GetHeader(APIKeyHeader)
+	if apiKey == "" {
+		c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "X-API-Key header is required"))
+		c.Abort()
+		return false
+	}
+
+	serviceName, ok := lookupAPIKey(apiKey)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Invalid or revoked API key"))
+		c.Abort()
+		return false
+	}
+
+	// 使用合成的服务身份，保证下游处理器可以正常读取用户信息
+	c.Set("user_id", uint(0))
+	c.Set("username", "service:"+serviceName)
+	c.Set("role", ServiceRole)
+	c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), 0))
+	return true
+}
+
+// lookupAPIKey 在配置中查找API Key对应的服务名称（常量时间比较）
+func lookupAPIKey(apiKey string) (string, bool) {
+	for key, serviceName := range config.AppConfig.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return serviceName, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"erp/pkg/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContextMiddleware 将客户端IP写入请求context，供审计日志记录
+func AuditContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(audit.WithClientIP(c.Request.Context(), GetClientIP(c)))
+		c.Next()
+	}
+}
@@ -17,7 +17,7 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, response.Error("Authorization header is required"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Authorization header is required"))
 			c.Abort()
 			return
 		}
@@ -25,7 +25,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 检查Bearer前缀
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, response.Error("Invalid authorization header format"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Invalid authorization header format"))
 			c.Abort()
 			return
 		}
@@ -33,7 +33,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		tokenString := tokenParts[1]
 		claims, err := auth.ParseToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, response.Error("Invalid or expired token"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Invalid or expired token"))
 			c.Abort()
 			return
 		}
@@ -42,6 +42,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("must_change_password", claims.MustChangePassword)
+		c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), claims.UserID))
 
 		c.Next()
 	}
@@ -52,7 +54,7 @@ func AuthMiddlewareWithPasswordValidation(userRepo *repository.Repository) gin.H
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, response.Error("Authorization header is required"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Authorization header is required"))
 			c.Abort()
 			return
 		}
@@ -60,7 +62,7 @@ func AuthMiddlewareWithPasswordValidation(userRepo *repository.Repository) gin.H
 		// 检查Bearer前缀
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, response.Error("Invalid authorization header format"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Invalid authorization header format"))
 			c.Abort()
 			return
 		}
@@ -68,7 +70,7 @@ func AuthMiddlewareWithPasswordValidation(userRepo *repository.Repository) gin.H
 		tokenString := tokenParts[1]
 		claims, err := auth.ParseToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, response.Error("Invalid or expired token"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Invalid or expired token"))
 			c.Abort()
 			return
 		}
@@ -76,13 +78,13 @@ func AuthMiddlewareWithPasswordValidation(userRepo *repository.Repository) gin.H
 		// 验证密码版本
 		currentPasswordVersion, err := userRepo.GetPasswordVersion(c, claims.UserID)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, response.Error("用户不存在"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "用户不存在"))
 			c.Abort()
 			return
 		}
 
 		if !auth.ValidateTokenPasswordVersion(claims, currentPasswordVersion) {
-			c.JSON(http.StatusUnauthorized, response.Error("Token已失效，请重新登录"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "Token已失效，请重新登录"))
 			c.Abort()
 			return
 		}
@@ -91,6 +93,21 @@ func AuthMiddlewareWithPasswordValidation(userRepo *repository.Repository) gin.H
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("must_change_password", claims.MustChangePassword)
+		c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), claims.UserID))
+
+		c.Next()
+	}
+}
+
+// RequirePasswordChangedMiddleware 要求用户已修改初始密码，否则拒绝访问（需在认证中间件之后使用）
+func RequirePasswordChangedMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("must_change_password") {
+			c.JSON(http.StatusForbidden, response.LocalizedError(c, "请先修改初始密码"))
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -101,7 +118,7 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, response.Error("User role not found"))
+			c.JSON(http.StatusUnauthorized, response.LocalizedError(c, "User role not found"))
 			c.Abort()
 			return
 		}
@@ -116,7 +133,7 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		}
 
 		if !hasRole {
-			c.JSON(http.StatusForbidden, response.Error("权限不足"))
+			c.JSON(http.StatusForbidden, response.LocalizedError(c, "权限不足"))
 			c.Abort()
 			return
 		}
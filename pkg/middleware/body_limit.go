@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodySizeLimitMiddleware 限制请求体大小，超出时返回413，避免超大请求耗尽内存
+// 按路由组分别挂载，不同接口可使用不同上限（见 routes 中的 bodyLimit）
+// Content-Length已知时直接拒绝；未知（分块传输）时先在上限内读取请求体，超出同样返回413，
+// 避免处理器绑定参数时把 *http.MaxBytesError 当作400返回
+func BodySizeLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, response.LocalizedError(c, "请求体过大"))
+			c.Abort()
+			return
+		}
+
+		if c.Request.ContentLength >= 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, response.LocalizedError(c, "请求体过大"))
+			} else {
+				c.JSON(http.StatusBadRequest, response.LocalizedError(c, "请求参数错误"))
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
@@ -1,17 +1,29 @@
 package middleware
 
 import (
+	"strconv"
+
+	"erp/config"
+
 	"github.com/gin-gonic/gin"
 )
 
+// corsExposeHeaders 允许前端跨域读取的响应头（令牌自动刷新、缓存校验、文件下载）
+const corsExposeHeaders = "X-New-Access-Token, X-New-Refresh-Token, X-Token-Refreshed, ETag, Content-Disposition"
+
 // CORSMiddleware CORS跨域中间件
 func CORSMiddleware() gin.HandlerFunc {
+	maxAge := strconv.Itoa(config.AppConfig.CORSMaxAgeSeconds)
+
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, Accept-Language, X-CSRF-Token, Authorization, X-API-Key, If-None-Match")
+		c.Header("Access-Control-Expose-Headers", corsExposeHeaders)
 
 		if c.Request.Method == "OPTIONS" {
+			// 缓存预检请求结果，减少OPTIONS请求次数
+			c.Header("Access-Control-Max-Age", maxAge)
 			c.AbortWithStatus(204)
 			return
 		}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagWriter 缓存响应内容，用于计算ETag
+type etagWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *etagWriter) WriteHeaderNow() {}
+
+func (w *etagWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *etagWriter) Status() int {
+	return w.status
+}
+
+func (w *etagWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *etagWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// ETagMiddleware 为GET请求的成功响应生成弱ETag（响应体哈希），
+// 请求头If-None-Match匹配时返回304，不再返回响应体
+func ETagMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &etagWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if writer.status == http.StatusOK {
+			sum := sha256.Sum256(writer.body.Bytes())
+			etag := fmt.Sprintf(`W/"%x"`, sum[:16])
+			original.Header().Set("ETag", etag)
+
+			if etagMatches(c.GetHeader("If-None-Match"), etag) {
+				original.WriteHeader(http.StatusNotModified)
+				original.WriteHeaderNow()
+				return
+			}
+		}
+
+		original.WriteHeader(writer.status)
+		_, _ = original.Write(writer.body.Bytes())
+	}
+}
+
+// etagMatches 判断If-None-Match是否匹配当前ETag（弱比较）
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	current := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
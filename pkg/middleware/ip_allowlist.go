@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+
+	"erp/config"
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPAllowlistMiddleware IP白名单中间件，拒绝不在允许网段内的请求
+// cidrs为空时不做任何限制；支持单个IP（视为/32或/128）
+func IPAllowlistMiddleware(cidrs []string) gin.HandlerFunc {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		// 配置已在启动时由 config.Validate 校验，这里仅跳过无法解析的项
+		network, err := config.ParseCIDR(cidr)
+		if err != nil {
+			slog.Error("IP白名单配置错误，已忽略", "cidr", cidr, "error", err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *gin.Context) {
+		if len(cidrs) == 0 {
+			c.Next()
+			return
+		}
+
+		ip := net.ParseIP(GetClientIP(c))
+		if ip == nil {
+			c.JSON(http.StatusForbidden, response.LocalizedError(c, "无法识别客户端IP"))
+			c.Abort()
+			return
+		}
+
+		for _, network := range networks {
+			if network.Contains(ip) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, response.LocalizedError(c, "当前IP不允许访问"))
+		c.Abort()
+	}
+}
+
+// GetClientIP 获取客户端IP
+// 开启 TRUST_PROXY_HEADERS 时，由gin从X-Forwarded-For最右侧开始跳过受信任代理（TRUSTED_PROXIES）取客户端IP，
+// 客户端自行填写的前置地址不会被采用；未开启时直接使用连接的远端地址
+func GetClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"erp/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware 根据Accept-Language请求头确定响应语言，默认中文
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(response.LocaleKey, parseAcceptLanguage(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// parseAcceptLanguage 按请求头中的顺序选取第一个支持的语言
+func parseAcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, response.LocaleZH):
+			return response.LocaleZH
+		case strings.HasPrefix(tag, response.LocaleEN):
+			return response.LocaleEN
+		}
+	}
+	return response.DefaultLocale
+}
@@ -3,21 +3,58 @@ package oss
 import (
 	"net/http"
 
+	auditModel "erp/internal/modules/audit/model"
+	"erp/pkg/audit"
+
 	"github.com/gin-gonic/gin"
 )
 
 // GetSTSTokenHandler 获取STS临时凭证处理器 (用于前端直传)
 // @Summary 获取STS临时凭证
-// @Description 为前端直传获取阿里云OSS STS临时访问凭证
+// @Description 为前端直传获取阿里云OSS STS临时访问凭证，凭证仅允许上传到 uploads/{用户ID}/{日期}/{prefix}/ 下
+// @Description STS授权策略无法限制文件类型（Content-Type），上传文件类型需由业务在使用文件时校验
 // @Tags OSS
 // @Produce json
+// @Security BearerAuth
+// @Param prefix query string true "上传目录，第一级必须是允许的目录（如 product-images、excel），仅允许字母、数字、下划线、短横线和斜杠"
 // @Success 200 {object} STSResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /oss/sts/token [get]
 func GetSTSTokenHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "未获取到用户信息",
+		})
+		return
+	}
+
+	// 校验上传目录
+	prefix := c.Query("prefix")
+	if err := ValidateUploadPrefix(prefix); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "获取STS凭证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 按用户和日期限定上传前缀
+	keyPrefix, err := UploadKeyPrefix(userID.(uint), prefix)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "获取STS凭证失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	// 获取STS临时凭证
-	credentials, err := GetSTSCredentials()
+	credentials, err := GetSTSCredentials(keyPrefix)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -27,6 +64,11 @@ func GetSTSTokenHandler(c *gin.Context) {
 		return
 	}
 
+	// 记录凭证申请人及上传范围
+	audit.Record(c.Request.Context(), auditModel.ActionIssueCredentials, "oss_sts", userID.(uint), map[string]interface{}{
+		"key_prefix": keyPrefix,
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "获取STS凭证成功",
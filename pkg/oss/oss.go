@@ -28,6 +28,9 @@ func InitOSS() error {
 	if config.AppConfig.OSSBucketName == "" {
 		return fmt.Errorf("OSS Bucket名称配置缺失")
 	}
+	if !config.IsKnownOSSRegion(config.AppConfig.OSSRegion) {
+		return fmt.Errorf("不支持的OSS区域: %s", config.AppConfig.OSSRegion)
+	}
 
 	return nil
 }
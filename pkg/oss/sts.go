@@ -1,8 +1,14 @@
 package oss
 
 import (
+	"encoding/json"
 	"erp/config"
 	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/alibabacloud-go/darabonba-openapi/v2/client"
 	sts20150401 "github.com/alibabacloud-go/sts-20150401/v2/client"
@@ -19,10 +25,148 @@ type STSResponse struct {
 	Region          string `json:"region"`
 	Bucket          string `json:"bucket"`
 	Endpoint        string `json:"endpoint"`
+	KeyPrefix       string `json:"keyPrefix"` // 允许上传的对象前缀
 }
 
-// GetSTSCredentials 获取STS临时访问凭证 (用于前端直传)
-func GetSTSCredentials() (*STSResponse, error) {
+// stsRefreshWindow 凭证距离过期不足该时间时重新获取
+const stsRefreshWindow = 5 * time.Minute
+
+// cachedCredentials 缓存的STS凭证
+type cachedCredentials struct {
+	credentials STSResponse
+	expires     time.Time
+}
+
+// stsCall 进行中的AssumeRole请求，同一前缀的并发请求等待同一次调用结果
+type stsCall struct {
+	done        chan struct{}
+	credentials *STSResponse
+	err         error
+}
+
+// STS凭证缓存，避免同一用户短时间内重复上传时每次都调用AssumeRole
+// 缓存键为完整上传前缀（uploads/{用户ID}/{日期}/[自定义前缀/]）：凭证的授权策略限定在该前缀下，
+// 不能在用户之间共享，因此只有同一用户当天对同一目录的重复请求才会命中缓存
+// stsCacheMu 只保护 stsCache 和 stsInflight 的读写，不在AssumeRole网络调用期间持有
+var (
+	stsCacheMu  sync.Mutex
+	stsCache    = make(map[string]cachedCredentials)
+	stsInflight = make(map[string]*stsCall)
+)
+
+// keyPrefixPattern 自定义上传前缀允许的字符
+var keyPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_\-/]*$`)
+
+// ValidateUploadPrefix 校验上传目录是否在允许列表中，目录的第一级必须是配置的上传目录之一
+// 凭证的授权策略只限制对象前缀；STS策略无法约束上传文件的Content-Type，因此不校验文件类型
+func ValidateUploadPrefix(prefix string) error {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return fmt.Errorf("上传目录不能为空")
+	}
+
+	root := strings.SplitN(prefix, "/", 2)[0]
+	for _, allowed := range config.AppConfig.OSSUploadPrefixes {
+		if allowed == root {
+			return nil
+		}
+	}
+	return fmt.Errorf("不允许的上传目录: %s", root)
+}
+
+// UploadKeyPrefix 生成用户的上传前缀：uploads/{用户ID}/{日期}/[自定义前缀/]
+func UploadKeyPrefix(userID uint, customPrefix string) (string, error) {
+	customPrefix = strings.Trim(customPrefix, "/")
+	if !keyPrefixPattern.MatchString(customPrefix) || strings.Contains(customPrefix, "//") {
+		return "", fmt.Errorf("无效的上传前缀")
+	}
+
+	prefix := fmt.Sprintf("uploads/%d/%s/", userID, time.Now().Format("20060102"))
+	if customPrefix != "" {
+		prefix += customPrefix + "/"
+	}
+	return prefix, nil
+}
+
+// GetSTSCredentials 获取STS临时访问凭证 (用于前端直传)，凭证仅允许上传到keyPrefix下
+// 缓存的凭证在距离过期超过 stsRefreshWindow 时直接复用；同一前缀并发未命中时只发起一次AssumeRole
+func GetSTSCredentials(keyPrefix string) (*STSResponse, error) {
+	stsCacheMu.Lock()
+	if cached, ok := stsCache[keyPrefix]; ok && time.Until(cached.expires) > stsRefreshWindow {
+		stsCacheMu.Unlock()
+		credentials := cached.credentials
+		return &credentials, nil
+	}
+	if call, ok := stsInflight[keyPrefix]; ok {
+		stsCacheMu.Unlock()
+		<-call.done
+		return copyCredentials(call.credentials), call.err
+	}
+	call := &stsCall{done: make(chan struct{})}
+	stsInflight[keyPrefix] = call
+	stsCacheMu.Unlock()
+
+	call.credentials, call.err = requestSTSCredentials(keyPrefix)
+
+	stsCacheMu.Lock()
+	delete(stsInflight, keyPrefix)
+	if call.err == nil {
+		cacheCredentials(keyPrefix, call.credentials)
+	}
+	stsCacheMu.Unlock()
+	close(call.done)
+
+	return copyCredentials(call.credentials), call.err
+}
+
+// cacheCredentials 写入凭证缓存并清理已过期的条目，调用方需持有 stsCacheMu
+func cacheCredentials(keyPrefix string, credentials *STSResponse) {
+	expires, err := time.Parse(time.RFC3339, credentials.Expiration)
+	if err != nil {
+		log.Printf("Warning: 无法解析STS凭证过期时间 %q，本次凭证不缓存: %v", credentials.Expiration, err)
+		return
+	}
+
+	// 清理已过期的缓存，避免按日期变化的前缀无限累积
+	for prefix, cached := range stsCache {
+		if time.Now().After(cached.expires) {
+			delete(stsCache, prefix)
+		}
+	}
+	stsCache[keyPrefix] = cachedCredentials{credentials: *credentials, expires: expires}
+}
+
+// copyCredentials 复制凭证，避免多个调用方共享同一对象
+func copyCredentials(credentials *STSResponse) *STSResponse {
+	if credentials == nil {
+		return nil
+	}
+	copied := *credentials
+	return &copied
+}
+
+// uploadPolicy 生成仅允许向指定前缀上传对象的授权策略
+func uploadPolicy(bucket, keyPrefix string) (string, error) {
+	policy := map[string]interface{}{
+		"Version": "1",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect":   "Allow",
+				"Action":   []string{"oss:PutObject"},
+				"Resource": []string{fmt.Sprintf("acs:oss:*:*:%s/%s*", bucket, keyPrefix)},
+			},
+		},
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// requestSTSCredentials 调用AssumeRole获取新的STS临时凭证
+func requestSTSCredentials(keyPrefix string) (*STSResponse, error) {
 	// 检查必要的配置
 	if config.AppConfig.OSSAccessKeyID == "" || config.AppConfig.OSSAccessKeySecret == "" {
 		return nil, fmt.Errorf("OSS AccessKey配置缺失")
@@ -31,11 +175,16 @@ func GetSTSCredentials() (*STSResponse, error) {
 		return nil, fmt.Errorf("OSS_ROLE_ARN配置缺失，前端直传需要此配置")
 	}
 
+	endpoint, err := getStsEndpoint(config.AppConfig.OSSRegion)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建STS客户端配置
 	stsConfig := &client.Config{
 		AccessKeyId:     tea.String(config.AppConfig.OSSAccessKeyID),
 		AccessKeySecret: tea.String(config.AppConfig.OSSAccessKeySecret),
-		Endpoint:        tea.String(getStsEndpoint(config.AppConfig.OSSRegion)),
+		Endpoint:        tea.String(endpoint),
 	}
 
 	// 创建STS客户端
@@ -44,11 +193,17 @@ func GetSTSCredentials() (*STSResponse, error) {
 		return nil, fmt.Errorf("创建STS客户端失败: %v", err)
 	}
 
+	policy, err := uploadPolicy(config.AppConfig.OSSBucketName, keyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("生成STS授权策略失败: %v", err)
+	}
+
 	// 准备AssumeRole请求
 	assumeRoleRequest := &sts20150401.AssumeRoleRequest{
 		RoleArn:         tea.String(config.AppConfig.OSSRoleARN),
 		RoleSessionName: tea.String(config.AppConfig.OSSRoleSessionName),
-		DurationSeconds: tea.Int64(3600), // 1小时有效期
+		DurationSeconds: tea.Int64(int64(config.AppConfig.OSSSTSDurationSeconds)),
+		Policy:          tea.String(policy),
 	}
 
 	// 发起AssumeRole请求
@@ -68,68 +223,16 @@ func GetSTSCredentials() (*STSResponse, error) {
 		Region:          config.AppConfig.OSSRegion,
 		Bucket:          config.AppConfig.OSSBucketName,
 		Endpoint:        "https://" + config.AppConfig.GetOSSEndpoint(),
+		KeyPrefix:       keyPrefix,
 	}
 
 	return stsResponse, nil
 }
 
-// getStsEndpoint 根据OSS区域获取对应的STS端点
-func getStsEndpoint(region string) string {
-	// 根据不同区域返回对应的STS端点
-	switch region {
-	case "cn-beijing":
-		return "sts.cn-beijing.aliyuncs.com"
-	case "cn-shanghai":
-		return "sts.cn-shanghai.aliyuncs.com"
-	case "cn-shenzhen":
-		return "sts.cn-shenzhen.aliyuncs.com"
-	case "cn-hangzhou":
-		return "sts.cn-hangzhou.aliyuncs.com"
-	case "cn-qingdao":
-		return "sts.cn-qingdao.aliyuncs.com"
-	case "cn-zhangjiakou":
-		return "sts.cn-zhangjiakou.aliyuncs.com"
-	case "cn-huhehaote":
-		return "sts.cn-huhehaote.aliyuncs.com"
-	case "cn-wulanchabu":
-		return "sts.cn-wulanchabu.aliyuncs.com"
-	case "cn-heyuan":
-		return "sts.cn-heyuan.aliyuncs.com"
-	case "cn-guangzhou":
-		return "sts.cn-guangzhou.aliyuncs.com"
-	case "cn-fuzhou":
-		return "sts.cn-fuzhou.aliyuncs.com"
-	case "cn-wuhan-lr":
-		return "sts.cn-wuhan-lr.aliyuncs.com"
-	case "cn-chengdu":
-		return "sts.cn-chengdu.aliyuncs.com"
-	case "cn-nanjing":
-		return "sts.cn-nanjing.aliyuncs.com"
-	// 海外区域
-	case "ap-southeast-1":
-		return "sts.ap-southeast-1.aliyuncs.com"
-	case "ap-southeast-2":
-		return "sts.ap-southeast-2.aliyuncs.com"
-	case "ap-southeast-3":
-		return "sts.ap-southeast-3.aliyuncs.com"
-	case "ap-southeast-5":
-		return "sts.ap-southeast-5.aliyuncs.com"
-	case "ap-northeast-1":
-		return "sts.ap-northeast-1.aliyuncs.com"
-	case "ap-south-1":
-		return "sts.ap-south-1.aliyuncs.com"
-	case "us-east-1":
-		return "sts.us-east-1.aliyuncs.com"
-	case "us-west-1":
-		return "sts.us-west-1.aliyuncs.com"
-	case "eu-west-1":
-		return "sts.eu-west-1.aliyuncs.com"
-	case "eu-central-1":
-		return "sts.eu-central-1.aliyuncs.com"
-	case "me-east-1":
-		return "sts.me-east-1.aliyuncs.com"
-	default:
-		// 默认使用杭州区域的STS端点
-		return "sts.cn-hangzhou.aliyuncs.com"
+// getStsEndpoint 根据OSS区域获取对应的STS端点，未知区域返回错误
+func getStsEndpoint(region string) (string, error) {
+	if !config.IsKnownOSSRegion(region) {
+		return "", fmt.Errorf("不支持的OSS区域: %s", region)
 	}
+	return "sts." + region + ".aliyuncs.com", nil
 }
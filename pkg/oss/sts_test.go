@@ -0,0 +1,29 @@
+package oss
+
+import "testing"
+
+func TestGetStsEndpoint(t *testing.T) {
+	tests := []struct {
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{"cn-beijing", "sts.cn-beijing.aliyuncs.com", false},
+		{"cn-hangzhou", "sts.cn-hangzhou.aliyuncs.com", false},
+		{"ap-southeast-1", "sts.ap-southeast-1.aliyuncs.com", false},
+		{"cn-nowhere", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			got, err := getStsEndpoint(tt.region)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getStsEndpoint(%q) error = %v, wantErr %v", tt.region, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("getStsEndpoint(%q) = %q, want %q", tt.region, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleKey 上下文中保存语言的键
+const LocaleKey = "locale"
+
+// 支持的语言
+const (
+	LocaleZH = "zh"
+	LocaleEN = "en"
+)
+
+// DefaultLocale 默认语言，默认语言直接返回消息原文，保持接口兼容
+const DefaultLocale = LocaleZH
+
+// messages 以中文（或原有）消息为键的翻译表
+var messages = map[string]map[string]string{
+	LocaleEN: {
+		// 通用
+		"请求参数错误":    "Invalid request parameters",
+		"权限不足":      "Permission denied",
+		"未获取到用户信息":  "User information not found",
+		"当前IP不允许访问": "Access from this IP address is not allowed",
+		"无法识别客户端IP": "Unable to determine client IP address",
+		"请求体过大":     "Request body too large",
+
+		// 认证
+		"用户名或密码错误":       "Invalid username or password",
+		"原密码错误":          "Old password is incorrect",
+		"账户已被禁用":         "Account is disabled",
+		"Token已失效，请重新登录": "Token is no longer valid, please log in again",
+		"请先修改初始密码":       "Please change your initial password first",
+
+		// 资源不存在
+		"用户不存在": "User not found",
+		"商品不存在": "Product not found",
+		"货源不存在": "Source not found",
+		"标签不存在": "Tag not found",
+		"颜色不存在": "Color not found",
+
+		// 冲突
+		"用户名已存在":       "Username already exists",
+		"邮箱已存在":        "Email already exists",
+		"邮箱已被其他用户使用":   "Email is already used by another user",
+		"数据已被他人修改，请刷新": "Data has been modified by someone else, please refresh",
+		"商品编码已存在":      "Product code already exists",
+	},
+}
+
+// GetLocale 获取当前请求的语言
+func GetLocale(c *gin.Context) string {
+	if locale := c.GetString(LocaleKey); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Localize 按当前请求的语言翻译消息，没有对应翻译时返回原文
+func Localize(c *gin.Context, message string) string {
+	if table, ok := messages[GetLocale(c)]; ok {
+		if translated, ok := table[message]; ok {
+			return translated
+		}
+	}
+	return message
+}
+
+// LocalizedError 按当前请求的语言返回错误响应
+func LocalizedError(c *gin.Context, message string) Response {
+	return Error(Localize(c, message))
+}
@@ -0,0 +1,30 @@
+package response
+
+import (
+	"strconv"
+
+	"erp/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageParams 解析分页参数，所有列表接口使用相同的规则：
+// page 小于1或无法解析时为1；每页数量小于1或无法解析时使用资源的默认值，超过资源的最大值时截断为最大值
+// sizeKey 为每页数量的查询参数名（通常为 page_size），resource 为 config.PageSizeLimits 中的资源名
+func PageParams(c *gin.Context, resource, sizeKey string) (page, pageSize int) {
+	defaultSize, maxSize := config.AppConfig.PageSizeLimits(resource)
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err = strconv.Atoi(c.Query(sizeKey))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultSize
+	}
+	if pageSize > maxSize {
+		pageSize = maxSize
+	}
+	return page, pageSize
+}
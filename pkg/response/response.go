@@ -12,6 +12,7 @@ type Response struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"` // 错误详情，例如参数校验失败的字段信息
 }
 
 // Success 成功响应
@@ -40,14 +41,14 @@ func HandleError(c *gin.Context, err error) {
 	// 根据错误类型返回不同的状态码
 	switch err.Error() {
 	case "用户名已存在", "邮箱已存在", "邮箱已被其他用户使用":
-		c.JSON(http.StatusConflict, Error(err.Error()))
+		c.JSON(http.StatusConflict, LocalizedError(c, err.Error()))
 	case "用户名或密码错误", "原密码错误", "账户已被禁用":
-		c.JSON(http.StatusUnauthorized, Error(err.Error()))
+		c.JSON(http.StatusUnauthorized, LocalizedError(c, err.Error()))
 	case "用户不存在":
-		c.JSON(http.StatusNotFound, Error(err.Error()))
+		c.JSON(http.StatusNotFound, LocalizedError(c, err.Error()))
 	case "权限不足":
-		c.JSON(http.StatusForbidden, Error(err.Error()))
+		c.JSON(http.StatusForbidden, LocalizedError(c, err.Error()))
 	default:
-		c.JSON(http.StatusInternalServerError, Error(err.Error()))
+		c.JSON(http.StatusInternalServerError, LocalizedError(c, err.Error()))
 	}
 }
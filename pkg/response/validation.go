@@ -0,0 +1,128 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// 使用json标签作为校验错误中的字段名，便于前端直接定位表单字段
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "" || name == "-" {
+				name = strings.SplitN(field.Tag.Get("form"), ",", 2)[0]
+			}
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// ValidationError 请求参数绑定失败时的错误响应，校验错误会转换为 字段→提示信息 的映射放在 details 中
+func ValidationError(c *gin.Context, err error) Response {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return Error(Localize(c, "请求参数错误") + ": " + err.Error())
+	}
+
+	locale := GetLocale(c)
+	details := make(map[string]string, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		if locale == LocaleEN {
+			details[fieldErr.Field()] = translateFieldErrorEN(fieldErr)
+		} else {
+			details[fieldErr.Field()] = translateFieldError(fieldErr)
+		}
+	}
+
+	return Response{
+		Success: false,
+		Error:   Localize(c, "请求参数错误"),
+		Details: details,
+	}
+}
+
+// translateFieldError 将单个字段校验错误翻译为中文提示
+func translateFieldError(fieldErr validator.FieldError) string {
+	isString, isCollection := fieldKind(fieldErr)
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "必填"
+	case "min":
+		if isString {
+			return fmt.Sprintf("长度不能少于%s个字符", fieldErr.Param())
+		}
+		if isCollection {
+			return fmt.Sprintf("至少需要%s项", fieldErr.Param())
+		}
+		return fmt.Sprintf("不能小于%s", fieldErr.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("长度不能超过%s个字符", fieldErr.Param())
+		}
+		if isCollection {
+			return fmt.Sprintf("最多允许%s项", fieldErr.Param())
+		}
+		return fmt.Sprintf("不能大于%s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("必须是以下值之一: %s", strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+	case "email":
+		return "邮箱格式不正确"
+	default:
+		return "格式不正确"
+	}
+}
+
+// translateFieldErrorEN 将单个字段校验错误翻译为英文提示
+func translateFieldErrorEN(fieldErr validator.FieldError) string {
+	isString, isCollection := fieldKind(fieldErr)
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		if isCollection {
+			return fmt.Sprintf("must contain at least %s items", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		if isCollection {
+			return fmt.Sprintf("must contain at most %s items", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+	case "email":
+		return "must be a valid email address"
+	default:
+		return "is invalid"
+	}
+}
+
+// fieldKind 判断字段是否为字符串或集合类型，用于选择长度/数量/数值提示
+func fieldKind(fieldErr validator.FieldError) (isString, isCollection bool) {
+	switch fieldErr.Kind() {
+	case reflect.String:
+		return true, false
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"erp/internal/modules/webhook/model"
+	"erp/internal/modules/webhook/repository"
+)
+
+// SignatureHeader 签名请求头，值为 sha256=HMAC-SHA256(请求体, 密钥)的十六进制
+const SignatureHeader = "X-Webhook-Signature"
+
+// EventHeader 事件类型请求头
+const EventHeader = "X-Webhook-Event"
+
+// queueSize 事件异步分发队列长度
+const queueSize = 1000
+
+// deliveryWorkers 投递协程数量，限制同时进行的投递（含重试等待）数量
+const deliveryWorkers = 8
+
+// maxAttempts 单个订阅的最大投递次数
+const maxAttempts = 3
+
+// initialBackoff 首次重试前的等待时间，之后按指数退避
+const initialBackoff = 2 * time.Second
+
+// requestTimeout 单次投递请求超时时间
+const requestTimeout = 10 * time.Second
+
+// Payload 投递的事件内容
+type Payload struct {
+	Event     string      `json:"event"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// deliveryJob 向单个订阅投递一个事件
+type deliveryJob struct {
+	webhook model.Webhook
+	event   string
+	body    []byte
+}
+
+var (
+	queue      chan Payload
+	jobs       chan deliveryJob
+	repo       repository.WebhookRepository
+	httpClient = &http.Client{Timeout: requestTimeout}
+)
+
+// Init 初始化Webhook分发器，启动后台分发协程和固定数量的投递协程
+func Init(webhookRepo repository.WebhookRepository) {
+	repo = webhookRepo
+	queue = make(chan Payload, queueSize)
+	jobs = make(chan deliveryJob, queueSize)
+
+	for i := 0; i < deliveryWorkers; i++ {
+		go func() {
+			for job := range jobs {
+				deliver(job.webhook, job.event, job.body)
+			}
+		}()
+	}
+
+	go func() {
+		for payload := range queue {
+			dispatch(payload)
+		}
+	}()
+}
+
+// Dispatch 异步分发事件给订阅了该事件的Webhook，不阻塞当前请求
+func Dispatch(event string, data interface{}) {
+	if queue == nil {
+		return
+	}
+
+	// 队列已满时丢弃，避免阻塞主请求
+	select {
+	case queue <- Payload{Event: event, Timestamp: time.Now(), Data: data}:
+	default:
+		slog.Warn("webhook queue full, event dropped", "event", event)
+	}
+}
+
+// dispatch 查找订阅并交给投递协程；投递队列已满时阻塞，由事件队列承担背压（事件队列满时丢弃新事件）
+func dispatch(payload Payload) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	webhooks, err := repo.ListActive(ctx)
+	cancel()
+	if err != nil {
+		slog.Warn("list webhook subscriptions failed", "event", payload.Event, "error", err)
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Warn("marshal webhook payload failed", "event", payload.Event, "error", err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Events.Contains(payload.Event) {
+			continue
+		}
+		jobs <- deliveryJob{webhook: webhook, event: payload.Event, body: body}
+	}
+}
+
+// deliver 投递事件，失败时按指数退避重试，并记录每次尝试
+func deliver(webhook model.Webhook, event string, body []byte) {
+	signature := sign(body, webhook.Secret)
+	backoff := initialBackoff
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		statusCode, err := send(webhook.URL, event, signature, body)
+		success := err == nil && statusCode >= 200 && statusCode < 300
+
+		delivery := &model.WebhookDelivery{
+			WebhookID:  webhook.ID,
+			Event:      event,
+			Attempt:    attempt,
+			StatusCode: statusCode,
+			Success:    success,
+			CreatedAt:  time.Now(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		} else if !success {
+			delivery.Error = fmt.Sprintf("unexpected status code %d", statusCode)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		if err := repo.CreateDelivery(ctx, delivery); err != nil {
+			slog.Warn("save webhook delivery failed", "webhook_id", webhook.ID, "event", event, "error", err)
+		}
+		cancel()
+
+		if success {
+			return
+		}
+		if attempt < maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	slog.Warn("webhook delivery failed", "webhook_id", webhook.ID, "event", event, "attempts", maxAttempts)
+}
+
+// send 发送一次投递请求，返回响应状态码
+func send(url, event, signature string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(SignatureHeader, "sha256="+signature)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// sign 计算请求体的HMAC-SHA256签名
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"erp/config"
 	"erp/internal/app"
 	"erp/internal/modules/user/repository"
 	"erp/pkg/middleware"
@@ -28,12 +29,26 @@ func SetupRoutes(r *gin.Engine, app *app.App) {
 
 		// OSS相关接口
 		setupOSSRoutes(api, app.GetUserRepository())
+
+		// 审计日志接口
+		setupAuditRoutes(api, app.Audit.GetHandler(), app.GetUserRepository())
+
+		// 系统维护接口
+		setupMaintenanceRoutes(api, app.Maintenance.GetHandler(), app.GetUserRepository())
+
+		// Webhook订阅接口
+		setupWebhookRoutes(api, app.Webhook.GetHandler(), app.GetUserRepository())
 	}
 }
 
+// bodyLimit 按MB构造请求体大小限制中间件，按路由组挂载，上限为 config.AppConfig.MaxRequestBodyMB
+func bodyLimit(mb int) gin.HandlerFunc {
+	return middleware.BodySizeLimitMiddleware(int64(mb) << 20)
+}
+
 // setupUserRoutes 设置用户相关路由
 func setupUserRoutes(api *gin.RouterGroup, userHandler interface{}, userRepo interface{}) {
-	user := api.Group("/user")
+	user := api.Group("/user", bodyLimit(config.AppConfig.MaxRequestBodyMB))
 	{
 		// 公开接口（无需认证）
 		user.POST("/register", userHandler.(interface{ Register(*gin.Context) }).Register)
@@ -44,16 +59,22 @@ func setupUserRoutes(api *gin.RouterGroup, userHandler interface{}, userRepo int
 		auth.Use(middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)))
 		{
 			auth.GET("/profile", userHandler.(interface{ GetProfile(*gin.Context) }).GetProfile)
-			auth.PUT("/profile", userHandler.(interface{ UpdateProfile(*gin.Context) }).UpdateProfile)
+			auth.PUT("/profile", middleware.RequirePasswordChangedMiddleware(), userHandler.(interface{ UpdateProfile(*gin.Context) }).UpdateProfile)
 			auth.POST("/change_password", userHandler.(interface{ ChangePassword(*gin.Context) }).ChangePassword)
 		}
 
 		// 管理员功能路由组（统一管理所有管理员权限相关的接口）
 		admin := user.Group("/admin")
-		admin.Use(middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)), middleware.RoleMiddleware("admin"))
+		admin.Use(
+			middleware.IPAllowlistMiddleware(config.AppConfig.AdminAllowedCIDRs),
+			middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)),
+			middleware.RequirePasswordChangedMiddleware(),
+			middleware.RoleMiddleware("admin"),
+		)
 		{
 			// 用户列表查询
 			admin.GET("/users", userHandler.(interface{ GetUsers(*gin.Context) }).GetUsers)
+			admin.GET("/users/export", userHandler.(interface{ ExportUsers(*gin.Context) }).ExportUsers)
 
 			// 用户管理操作
 			admin.POST("/users", userHandler.(interface{ AdminCreateUser(*gin.Context) }).AdminCreateUser)
@@ -68,31 +89,50 @@ func setupUserRoutes(api *gin.RouterGroup, userHandler interface{}, userRepo int
 
 // setupProductRoutes 设置商品相关路由
 func setupProductRoutes(api *gin.RouterGroup, productHandler interface{}, userRepo interface{}) {
-	product := api.Group("/product")
+	product := api.Group("/product", bodyLimit(config.AppConfig.MaxRequestBodyMB))
 	{
-		// 需要认证的接口
+		// 需要认证的接口（支持JWT或API Key认证）
 		auth := product.Group("")
-		auth.Use(middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)))
+		auth.Use(middleware.AuthOrAPIKeyMiddleware(userRepo.(*repository.Repository)), middleware.RequirePasswordChangedMiddleware())
 		{
 			// 商品管理
 			auth.POST("", productHandler.(interface{ Create(*gin.Context) }).Create)
 			auth.GET("", productHandler.(interface{ List(*gin.Context) }).List)
-			auth.GET("/:id", productHandler.(interface{ Get(*gin.Context) }).Get)
+			auth.GET("/:id", middleware.ETagMiddleware(), productHandler.(interface{ Get(*gin.Context) }).Get)
 			auth.PUT("/:id", productHandler.(interface{ Update(*gin.Context) }).Update)
 			auth.DELETE("/:id", productHandler.(interface{ Delete(*gin.Context) }).Delete)
 
+			// 低库存商品
+			auth.GET("/low-stock", productHandler.(interface{ ListLowStock(*gin.Context) }).ListLowStock)
+			// 缺少图片或主图的商品
+			auth.GET("/without-images", productHandler.(interface{ ListWithoutImages(*gin.Context) }).ListWithoutImages)
+
 			// 通过商品编码获取商品
 			auth.GET("/code/:code", productHandler.(interface{ GetByCode(*gin.Context) }).GetByCode)
+			// 商品查询历史
+			auth.GET("/query-history", productHandler.(interface{ ListQueryHistory(*gin.Context) }).ListQueryHistory)
+			// 通过商品编码精确获取商品（扫码枪）
+			auth.GET("/product-code/:code", productHandler.(interface{ GetByProductCode(*gin.Context) }).GetByProductCode)
 			// 通过SKU获取商品
 			auth.GET("/sku/:sku", productHandler.(interface{ GetBySKU(*gin.Context) }).GetBySKU)
 
 			// 图片管理
 			auth.PUT("/:id/images/order", productHandler.(interface{ UpdateImageOrder(*gin.Context) }).UpdateImageOrder)
 			auth.PUT("/:id/images/main", productHandler.(interface{ SetMainImage(*gin.Context) }).SetMainImage)
+			auth.POST("/:id/images/move", productHandler.(interface{ MoveImage(*gin.Context) }).MoveImage)
+			// 批量设置货源
+			auth.PATCH("/source", productHandler.(interface{ AssignSource(*gin.Context) }).AssignSource)
+			auth.DELETE("/:id/colors", productHandler.(interface{ ClearColors(*gin.Context) }).ClearColors)
+			auth.DELETE("/:id/colors/:color_id", productHandler.(interface{ RemoveColor(*gin.Context) }).RemoveColor)
+			auth.PUT("/:id/tags", productHandler.(interface{ SetTags(*gin.Context) }).SetTags)
+			auth.POST("/:id/clone", productHandler.(interface{ Clone(*gin.Context) }).Clone)
+			// 相关商品（按共享标签排序）
+			auth.GET("/:id/related", productHandler.(interface{ ListRelated(*gin.Context) }).ListRelated)
 
 			// 颜色管理
 			auth.POST("/colors", productHandler.(interface{ CreateColor(*gin.Context) }).CreateColor)
 			auth.GET("/colors", productHandler.(interface{ ListColors(*gin.Context) }).ListColors)
+			auth.GET("/colors/batch", productHandler.(interface{ GetColorsBatch(*gin.Context) }).GetColorsBatch)
 			auth.GET("/colors/:id", productHandler.(interface{ GetColor(*gin.Context) }).GetColor)
 			auth.PUT("/colors/:id", productHandler.(interface{ UpdateColor(*gin.Context) }).UpdateColor)
 			auth.DELETE("/colors/:id", productHandler.(interface{ DeleteColor(*gin.Context) }).DeleteColor)
@@ -102,11 +142,11 @@ func setupProductRoutes(api *gin.RouterGroup, productHandler interface{}, userRe
 
 // setupSourceRoutes 设置货源相关路由
 func setupSourceRoutes(api *gin.RouterGroup, sourceHandler interface{}, userRepo interface{}) {
-	source := api.Group("/source")
+	source := api.Group("/source", bodyLimit(config.AppConfig.MaxRequestBodyMB))
 	{
-		// 需要认证的接口
+		// 需要认证的接口（支持JWT或API Key认证）
 		auth := source.Group("")
-		auth.Use(middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)))
+		auth.Use(middleware.AuthOrAPIKeyMiddleware(userRepo.(*repository.Repository)), middleware.RequirePasswordChangedMiddleware())
 		{
 			// 货源基本操作
 			auth.POST("", sourceHandler.(interface{ Create(*gin.Context) }).Create)
@@ -117,17 +157,21 @@ func setupSourceRoutes(api *gin.RouterGroup, sourceHandler interface{}, userRepo
 
 			// 获取启用状态的货源列表
 			auth.GET("/active", sourceHandler.(interface{ ListActive(*gin.Context) }).ListActive)
+
+			// 货源商品数量统计
+			auth.GET("/product-counts", sourceHandler.(interface{ ProductCounts(*gin.Context) }).ProductCounts)
+			auth.GET("/:id/product-count", sourceHandler.(interface{ ProductCount(*gin.Context) }).ProductCount)
 		}
 	}
 }
 
 // setupTagsRoutes 设置标签相关路由
 func setupTagsRoutes(api *gin.RouterGroup, tagsHandler interface{}, userRepo interface{}) {
-	tags := api.Group("/tags")
+	tags := api.Group("/tags", bodyLimit(config.AppConfig.MaxRequestBodyMB))
 	{
 		// 需要认证的接口
 		auth := tags.Group("")
-		auth.Use(middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)))
+		auth.Use(middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)), middleware.RequirePasswordChangedMiddleware())
 		{
 			// 标签基本操作
 			auth.POST("", tagsHandler.(interface{ CreateTag(*gin.Context) }).CreateTag)
@@ -136,6 +180,7 @@ func setupTagsRoutes(api *gin.RouterGroup, tagsHandler interface{}, userRepo int
 			auth.GET("/:id", tagsHandler.(interface{ GetTagByID(*gin.Context) }).GetTagByID)
 			auth.PUT("/:id", tagsHandler.(interface{ UpdateTag(*gin.Context) }).UpdateTag)
 			auth.DELETE("/:id", tagsHandler.(interface{ DeleteTag(*gin.Context) }).DeleteTag)
+			auth.POST("/merge", tagsHandler.(interface{ MergeTags(*gin.Context) }).MergeTags)
 
 			// 标签与产品关联操作
 			auth.GET("/:id/products", tagsHandler.(interface{ GetProductsByTag(*gin.Context) }).GetProductsByTag)
@@ -154,6 +199,55 @@ func setupOSSRoutes(api *gin.RouterGroup, userRepo interface{}) {
 	{
 		// 获取STS临时凭证 (用于前端直传)
 		// 这个接口需要认证，确保只有登录用户才能获取上传凭证
-		ossGroup.GET("/sts/token", middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)), oss.GetSTSTokenHandler)
+		ossGroup.GET("/sts/token", middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)), middleware.RequirePasswordChangedMiddleware(), oss.GetSTSTokenHandler)
+	}
+}
+
+// setupAuditRoutes 设置审计日志相关路由
+func setupAuditRoutes(api *gin.RouterGroup, auditHandler interface{}, userRepo interface{}) {
+	auditLogs := api.Group("/audit-logs")
+	auditLogs.Use(
+		middleware.IPAllowlistMiddleware(config.AppConfig.AdminAllowedCIDRs),
+		middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)),
+		middleware.RequirePasswordChangedMiddleware(),
+		middleware.RoleMiddleware("admin"),
+	)
+	{
+		auditLogs.GET("", auditHandler.(interface{ List(*gin.Context) }).List)
+	}
+}
+
+// setupMaintenanceRoutes 设置系统维护相关路由
+func setupMaintenanceRoutes(api *gin.RouterGroup, maintenanceHandler interface{}, userRepo interface{}) {
+	maintenance := api.Group("/admin/maintenance")
+	maintenance.Use(
+		bodyLimit(config.AppConfig.MaxRequestBodyMB),
+		middleware.IPAllowlistMiddleware(config.AppConfig.AdminAllowedCIDRs),
+		middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)),
+		middleware.RequirePasswordChangedMiddleware(),
+		middleware.RoleMiddleware("admin"),
+	)
+	{
+		maintenance.POST("/purge-deleted", maintenanceHandler.(interface{ PurgeDeleted(*gin.Context) }).PurgeDeleted)
+	}
+}
+
+// setupWebhookRoutes 设置Webhook订阅相关路由
+func setupWebhookRoutes(api *gin.RouterGroup, webhookHandler interface{}, userRepo interface{}) {
+	webhooks := api.Group("/webhooks")
+	webhooks.Use(
+		bodyLimit(config.AppConfig.MaxRequestBodyMB),
+		middleware.IPAllowlistMiddleware(config.AppConfig.AdminAllowedCIDRs),
+		middleware.AuthMiddlewareWithPasswordValidation(userRepo.(*repository.Repository)),
+		middleware.RequirePasswordChangedMiddleware(),
+		middleware.RoleMiddleware("admin"),
+	)
+	{
+		webhooks.POST("", webhookHandler.(interface{ Create(*gin.Context) }).Create)
+		webhooks.GET("", webhookHandler.(interface{ List(*gin.Context) }).List)
+		webhooks.GET("/:id", webhookHandler.(interface{ Get(*gin.Context) }).Get)
+		webhooks.PUT("/:id", webhookHandler.(interface{ Update(*gin.Context) }).Update)
+		webhooks.DELETE("/:id", webhookHandler.(interface{ Delete(*gin.Context) }).Delete)
+		webhooks.GET("/:id/deliveries", webhookHandler.(interface{ ListDeliveries(*gin.Context) }).ListDeliveries)
 	}
 }